@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"io"
-	"mime/multipart"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/intelowlproject/go-intelowl/constants"
 )
@@ -23,6 +25,7 @@ type BasicAnalysisParams struct {
 }
 
 // ObservableAnalysisParams represents the fields needed to make an observable analysis.
+// An empty ObservableClassification is filled in with ClassifyObservable.
 type ObservableAnalysisParams struct {
 	BasicAnalysisParams
 	ObservableName           string `json:"observable_name"`
@@ -30,21 +33,34 @@ type ObservableAnalysisParams struct {
 }
 
 // MultipleObservableAnalysisParams represents the fields needed to analyze multiple observables.
+// Observables are [classification, observable name] pairs: an empty classification or a lone observable name
+// is classified with ClassifyObservable, as are the ObservableNames which are analyzed after the Observables.
 type MultipleObservableAnalysisParams struct {
 	BasicAnalysisParams
-	Observables [][]string `json:"observables"`
+	Observables     [][]string `json:"observables"`
+	ObservableNames []string   `json:"-"`
 }
 
 // FileAnalysisParams represents the fields needed to analyze a file.
+// Set either File or Source.
 type FileAnalysisParams struct {
 	BasicAnalysisParams
 	File *os.File
+	// Source is the file to analyze when it is not an *os.File e.g: NewFileSourceFromBytes
+	Source *FileSource `json:"-"`
+	// OnProgress is called while the file is uploaded
+	OnProgress UploadProgressFunc `json:"-"`
 }
 
 // MultipleFileAnalysisParams represents the fields needed to analyze multiple files.
+// The Sources are analyzed after the Files.
 type MultipleFileAnalysisParams struct {
 	BasicAnalysisParams
 	Files []*os.File
+	// Sources are the files to analyze that are not *os.Files e.g: NewFileSourceFromReader
+	Sources []*FileSource `json:"-"`
+	// OnProgress is called while every file is uploaded
+	OnProgress UploadProgressFunc `json:"-"`
 }
 
 // AnalysisResponse represents a response returned by the API when you analyze an observable or file.
@@ -71,10 +87,10 @@ func (client *IntelOwlClient) CreateObservableAnalysis(ctx context.Context, para
 	requestUrl := client.options.Url + constants.ANALYZE_OBSERVABLE_URL
 	method := "POST"
 	contentType := "application/json"
-	jsonData, _ := json.Marshal(params)
+	jsonData, _ := json.Marshal(params.classified())
 	body := bytes.NewBuffer(jsonData)
 
-	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
+	request, err := client.buildRequest(ctx, "AnalysisService.CreateObservableAnalysis", method, contentType, body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -100,10 +116,10 @@ func (client *IntelOwlClient) CreateMultipleObservableAnalysis(ctx context.Conte
 	requestUrl := client.options.Url + constants.ANALYZE_MULTIPLE_OBSERVABLES_URL
 	method := "POST"
 	contentType := "application/json"
-	jsonData, _ := json.Marshal(params)
+	jsonData, _ := json.Marshal(params.classified())
 	body := bytes.NewBuffer(jsonData)
 
-	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
+	request, err := client.buildRequest(ctx, "AnalysisService.CreateMultipleObservableAnalysis", method, contentType, body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -126,63 +142,26 @@ func (client *IntelOwlClient) CreateMultipleObservableAnalysis(ctx context.Conte
 // IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/analyze_file
 func (client *IntelOwlClient) CreateFileAnalysis(ctx context.Context, fileAnalysisParams *FileAnalysisParams) (*AnalysisResponse, error) {
 	requestUrl := client.options.Url + constants.ANALYZE_FILE_URL
-	// * Making the multiform data
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// * Adding the TLP field
-	writeTlpError := writer.WriteField("tlp", fileAnalysisParams.Tlp.String())
-	if writeTlpError != nil {
-		return nil, writeTlpError
-	}
-	// * Adding the runtimeconfiguration field
-	runTimeConfigurationJson, marshalError := json.Marshal(fileAnalysisParams.RuntimeConfiguration)
-	if marshalError != nil {
-		return nil, marshalError
-	}
-	runTimeConfigurationJsonString := string(runTimeConfigurationJson)
-	writeRuntimeError := writer.WriteField("runtime_configuration", runTimeConfigurationJsonString)
-	if writeRuntimeError != nil {
-		return nil, writeRuntimeError
-	}
-
-	// * Adding the requested analyzers
-	for _, analyzer := range fileAnalysisParams.AnalyzersRequested {
-		writeAnalyzerError := writer.WriteField("analyzers_requested", analyzer)
-		if writeAnalyzerError != nil {
-			return nil, writeAnalyzerError
-		}
+	// * Making the multiform data, the file is streamed while the request is sent
+	body := newMultipartBody(ctx, fileAnalysisParams.OnProgress)
+	if err := body.addBasicAnalysisParams(&fileAnalysisParams.BasicAnalysisParams); err != nil {
+		return nil, err
 	}
-
-	// * Adding the requested connectors
-	for _, connector := range fileAnalysisParams.ConnectorsRequested {
-		writeConnectorError := writer.WriteField("connectors_requested", connector)
-		if writeConnectorError != nil {
-			return nil, writeConnectorError
-		}
+	var err error
+	switch {
+	case fileAnalysisParams.File != nil && fileAnalysisParams.Source != nil:
+		err = errors.New("Set either the File or the Source to analyze, not both")
+	case fileAnalysisParams.Source != nil:
+		err = body.addSource("file", fileAnalysisParams.Source)
+	default:
+		err = body.addFile("file", fileAnalysisParams.File)
 	}
-
-	// * Adding the tag labels
-	for _, tagLabel := range fileAnalysisParams.TagsLabels {
-		writeTagLabelError := writer.WriteField("tags_labels", tagLabel)
-		if writeTagLabelError != nil {
-			return nil, writeTagLabelError
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	// * Adding the file!
-	filePart, _ := writer.CreateFormFile("file", filepath.Base(fileAnalysisParams.File.Name()))
-	_, writeFileError := io.Copy(filePart, fileAnalysisParams.File)
-	if writeFileError != nil {
-		writer.Close()
-		return nil, writeFileError
-	}
-	writer.Close()
-
 	//* building the request!
-	contentType := writer.FormDataContentType()
-	method := "POST"
-	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
+	request, err := client.buildMultipartRequest(ctx, "AnalysisService.CreateFileAnalysis", body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -204,76 +183,184 @@ func (client *IntelOwlClient) CreateFileAnalysis(ctx context.Context, fileAnalys
 // IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/analyze_multiple_files
 func (client *IntelOwlClient) CreateMultipleFileAnalysis(ctx context.Context, fileAnalysisParams *MultipleFileAnalysisParams) (*MultipleAnalysisResponse, error) {
 	requestUrl := client.options.Url + constants.ANALYZE_MULTIPLE_FILES_URL
-	// * Making the multiform data
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+	// * Making the multiform data, the files are streamed while the request is sent
+	body := newMultipartBody(ctx, fileAnalysisParams.OnProgress)
+	if err := body.addBasicAnalysisParams(&fileAnalysisParams.BasicAnalysisParams); err != nil {
+		return nil, err
+	}
+	for _, file := range fileAnalysisParams.Files {
+		if err := body.addFile("files", file); err != nil {
+			return nil, err
+		}
+	}
+	for _, fileSource := range fileAnalysisParams.Sources {
+		if err := body.addSource("files", fileSource); err != nil {
+			return nil, err
+		}
+	}
 
-	// * Adding the TLP field
-	writeTlpError := writer.WriteField("tlp", fileAnalysisParams.Tlp.String())
-	if writeTlpError != nil {
-		return nil, writeTlpError
+	//* building the request!
+	request, err := client.buildMultipartRequest(ctx, "AnalysisService.CreateMultipleFileAnalysis", body, requestUrl)
+	if err != nil {
+		return nil, err
 	}
-	// * Adding the runtimeconfiguration field
-	runTimeConfigurationJson, marshalError := json.Marshal(fileAnalysisParams.RuntimeConfiguration)
-	if marshalError != nil {
-		return nil, marshalError
+
+	multipleAnalysisResponse := MultipleAnalysisResponse{}
+	successResp, err := client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
 	}
-	runTimeConfigurationJsonString := string(runTimeConfigurationJson)
-	writeRuntimeError := writer.WriteField("runtime_configuration", runTimeConfigurationJsonString)
-	if writeRuntimeError != nil {
-		return nil, writeRuntimeError
+	if unmarshalError := json.Unmarshal(successResp.Data, &multipleAnalysisResponse); unmarshalError != nil {
+		return nil, unmarshalError
 	}
+	return &multipleAnalysisResponse, nil
+}
 
-	// * Adding the requested analyzers
-	for _, analyzer := range fileAnalysisParams.AnalyzersRequested {
-		writeAnalyzerError := writer.WriteField("analyzers_requested", analyzer)
-		if writeAnalyzerError != nil {
-			return nil, writeAnalyzerError
-		}
+// AnalysisResult represents the outcome of a single observable or file of a batch analysis.
+// Error is set when the job could not be created, could not be followed, or failed.
+type AnalysisResult struct {
+	// Input is the observable name or the file name that has been analyzed
+	Input string
+	JobID int
+	Job   *Job
+	Error error
+}
+
+// AnalysisResults are the outcomes of a batch analysis, in the order of its inputs.
+type AnalysisResults []*AnalysisResult
+
+// ByInput groups the results by their input. An input analyzed more than once keeps every one of its results,
+// in the order of the batch.
+func (results AnalysisResults) ByInput() map[string][]*AnalysisResult {
+	resultsByInput := make(map[string][]*AnalysisResult, len(results))
+	for _, result := range results {
+		resultsByInput[result.Input] = append(resultsByInput[result.Input], result)
 	}
+	return resultsByInput
+}
 
-	// * Adding the requested connectors
-	for _, connector := range fileAnalysisParams.ConnectorsRequested {
-		writeConnectorError := writer.WriteField("connectors_requested", connector)
-		if writeConnectorError != nil {
-			return nil, writeConnectorError
-		}
+// waitForAnalysis follows the job created by an analysis until it is terminal.
+func (client *IntelOwlClient) waitForAnalysis(ctx context.Context, analysisResponse *AnalysisResponse, waitOptions *WaitOptions) (*Job, error) {
+	if analysisResponse.JobID <= 0 {
+		return nil, fmt.Errorf("No job was created (status: %s, warnings: %s)", analysisResponse.Status, strings.Join(analysisResponse.Warnings, "; "))
 	}
+	job, err := client.JobService.Wait(ctx, uint64(analysisResponse.JobID), waitOptions)
+	if err != nil {
+		return nil, err
+	}
+	return job, checkJobSucceeded(job)
+}
 
-	// * Adding the tag labels
-	for _, tagLabel := range fileAnalysisParams.TagsLabels {
-		writeTagLabelError := writer.WriteField("tags_labels", tagLabel)
-		if writeTagLabelError != nil {
-			return nil, writeTagLabelError
-		}
+// waitForMultipleAnalysis follows every job created by a batch analysis, up to WaitOptions.MaxConcurrentWaits
+// at once. The results are in the order of the inputs.
+func (client *IntelOwlClient) waitForMultipleAnalysis(ctx context.Context, inputs []string, multipleAnalysisResponse *MultipleAnalysisResponse, waitOptions *WaitOptions) (AnalysisResults, error) {
+	if len(multipleAnalysisResponse.Results) != len(inputs) {
+		return nil, fmt.Errorf("Expected %d analysis results, got %d", len(inputs), len(multipleAnalysisResponse.Results))
+	}
+	results := make(AnalysisResults, len(inputs))
+	indexes := make(chan int)
+	workers := waitOptions.withDefaults().MaxConcurrentWaits
+	if workers > len(inputs) {
+		workers = len(inputs)
 	}
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range indexes {
+				analysisResponse := multipleAnalysisResponse.Results[index]
+				job, err := client.waitForAnalysis(ctx, &analysisResponse, waitOptions)
+				results[index] = &AnalysisResult{
+					Input: inputs[index],
+					JobID: analysisResponse.JobID,
+					Job:   job,
+					Error: err,
+				}
+			}
+		}()
+	}
+	for index := range inputs {
+		indexes <- index
+	}
+	close(indexes)
+	waitGroup.Wait()
+	return results, ctx.Err()
+}
 
-	// * Adding the files!
-	for _, file := range fileAnalysisParams.Files {
-		filePart, _ := writer.CreateFormFile("files", filepath.Base(file.Name()))
-		_, writeFileError := io.Copy(filePart, file)
-		if writeFileError != nil {
-			writer.Close()
-			return nil, writeFileError
+// AnalyzeObservableAndWait analyzes an observable and waits until its job is terminal.
+// The returned error is a JobError if the job failed or was killed.
+//
+//	Endpoints: POST /api/analyze_observable and GET /api/jobs/{jobID}
+//
+// IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/analyze_observable
+func (client *IntelOwlClient) AnalyzeObservableAndWait(ctx context.Context, params *ObservableAnalysisParams, waitOptions *WaitOptions) (*Job, error) {
+	analysisResponse, err := client.CreateObservableAnalysis(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return client.waitForAnalysis(ctx, analysisResponse, waitOptions)
+}
+
+// AnalyzeMultipleObservablesAndWait analyzes multiple observables and waits until all of their jobs are terminal.
+// The results are in the order of the observables, even if the same observable is analyzed twice, and each of them
+// carries its own error, so one failing job does not hide the others. AnalysisResults.ByInput keys them by observable.
+// The WaitOptions.OnPoll callback is called concurrently for every job.
+//
+//	Endpoints: POST /api/analyze_multiple_observables and GET /api/jobs/{jobID}
+//
+// IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/analyze_multiple_observables
+func (client *IntelOwlClient) AnalyzeMultipleObservablesAndWait(ctx context.Context, params *MultipleObservableAnalysisParams, waitOptions *WaitOptions) (AnalysisResults, error) {
+	multipleAnalysisResponse, err := client.CreateMultipleObservableAnalysis(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	observablePairs := params.observablePairs()
+	observableNames := make([]string, 0, len(observablePairs))
+	for _, observable := range observablePairs {
+		observableName := ""
+		if len(observable) > 0 {
+			observableName = observable[len(observable)-1]
 		}
+		observableNames = append(observableNames, observableName)
 	}
-	writer.Close()
+	return client.waitForMultipleAnalysis(ctx, observableNames, multipleAnalysisResponse, waitOptions)
+}
 
-	//* building the request!
-	contentType := writer.FormDataContentType()
-	method := "POST"
-	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
+// AnalyzeFileAndWait analyzes a file and waits until its job is terminal.
+// The returned error is a JobError if the job failed or was killed.
+//
+//	Endpoints: POST /api/analyze_file and GET /api/jobs/{jobID}
+//
+// IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/analyze_file
+func (client *IntelOwlClient) AnalyzeFileAndWait(ctx context.Context, fileAnalysisParams *FileAnalysisParams, waitOptions *WaitOptions) (*Job, error) {
+	analysisResponse, err := client.CreateFileAnalysis(ctx, fileAnalysisParams)
 	if err != nil {
 		return nil, err
 	}
+	return client.waitForAnalysis(ctx, analysisResponse, waitOptions)
+}
 
-	multipleAnalysisResponse := MultipleAnalysisResponse{}
-	successResp, err := client.newRequest(ctx, request)
+// AnalyzeMultipleFilesAndWait analyzes multiple files and waits until all of their jobs are terminal.
+// The results are in the order of the files, Files then Sources, even if two files have the same name, and each
+// of them carries its own error, so one failing job does not hide the others. AnalysisResults.ByInput keys them by
+// file name.
+// The WaitOptions.OnPoll callback is called concurrently for every job.
+//
+//	Endpoints: POST /api/analyze_mutliple_files and GET /api/jobs/{jobID}
+//
+// IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/analyze_multiple_files
+func (client *IntelOwlClient) AnalyzeMultipleFilesAndWait(ctx context.Context, fileAnalysisParams *MultipleFileAnalysisParams, waitOptions *WaitOptions) (AnalysisResults, error) {
+	multipleAnalysisResponse, err := client.CreateMultipleFileAnalysis(ctx, fileAnalysisParams)
 	if err != nil {
 		return nil, err
 	}
-	if unmarshalError := json.Unmarshal(successResp.Data, &multipleAnalysisResponse); unmarshalError != nil {
-		return nil, unmarshalError
+	fileNames := make([]string, 0, len(fileAnalysisParams.Files)+len(fileAnalysisParams.Sources))
+	for _, file := range fileAnalysisParams.Files {
+		fileNames = append(fileNames, filepath.Base(file.Name()))
 	}
-	return &multipleAnalysisResponse, nil
+	for _, fileSource := range fileAnalysisParams.Sources {
+		fileNames = append(fileNames, fileSource.Name)
+	}
+	return client.waitForMultipleAnalysis(ctx, fileNames, multipleAnalysisResponse, waitOptions)
 }
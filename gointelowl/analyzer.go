@@ -25,6 +25,23 @@ type AnalyzerConfig struct {
 	ObservableSupported   []string `json:"observable_supported"`
 }
 
+// SupportsObservable reports whether the analyzer analyzes observables with the given classification e.g: "ip".
+func (analyzerConfig *AnalyzerConfig) SupportsObservable(classification string) bool {
+	return analyzerConfig.Type == "observable" && containsString(analyzerConfig.ObservableSupported, classification)
+}
+
+// SupportsMimetype reports whether the analyzer analyzes files with the given mimetype e.g: "application/pdf".
+// An empty SupportedFiletypes means every mimetype that is not in NotSupportedFiletypes.
+func (analyzerConfig *AnalyzerConfig) SupportsMimetype(mimetype string) bool {
+	if analyzerConfig.Type != "file" {
+		return false
+	}
+	if len(analyzerConfig.SupportedFiletypes) > 0 && !containsString(analyzerConfig.SupportedFiletypes, mimetype) {
+		return false
+	}
+	return !containsString(analyzerConfig.NotSupportedFiletypes, mimetype)
+}
+
 // AnalyzerService handles communication with analyzer related methods of the IntelOwl API.
 //
 // IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/analyzer
@@ -41,7 +58,7 @@ func (analyzerService *AnalyzerService) GetConfigs(ctx context.Context) (*[]Anal
 	requestUrl := analyzerService.client.options.Url + constants.ANALYZER_CONFIG_URL
 	contentType := "application/json"
 	method := "GET"
-	request, err := analyzerService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := analyzerService.client.buildRequest(ctx, "AnalyzerService.GetConfigs", method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +97,7 @@ func (analyzerService *AnalyzerService) HealthCheck(ctx context.Context, analyze
 	requestUrl := fmt.Sprintf(route, analyzerName)
 	contentType := "application/json"
 	method := "GET"
-	request, err := analyzerService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := analyzerService.client.buildRequest(ctx, "AnalyzerService.HealthCheck", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,48 @@
+package gointelowl
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// backoffDelay calculates how long to wait before the given attempt (starting from 1)
+// using an exponential backoff capped at maxDelay.
+// jitter is the fraction (0 to 1) of the delay that gets randomized.
+func backoffDelay(attempt int, baseDelay time.Duration, maxDelay time.Duration, multiplier float64, jitter float64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(baseDelay) * math.Pow(multiplier, float64(attempt-1))
+	if maxDelay > 0 && delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+	if jitter > 0 {
+		if jitter > 1 {
+			jitter = 1
+		}
+		// * spreading the delay evenly in [delay - jitter*delay, delay + jitter*delay]
+		delta := jitter * delay
+		delay = delay - delta + rand.Float64()*2*delta
+	}
+	return time.Duration(delay)
+}
+
+// sleepContext waits for the given duration or until the context is done, whichever comes first.
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,122 @@
+package gointelowl
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Values of the observable classification used in IntelOwl's REST API.
+const (
+	IPClassification      = "ip"
+	DomainClassification  = "domain"
+	URLClassification     = "url"
+	HashClassification    = "hash"
+	GenericClassification = "generic"
+)
+
+// Hash algorithms told apart by HashAlgorithm.
+const (
+	MD5Hash    = "md5"
+	SHA1Hash   = "sha1"
+	SHA256Hash = "sha256"
+	SHA512Hash = "sha512"
+)
+
+// The rules IntelOwl uses to classify observables.
+//
+// IntelOwl source: https://github.com/intelowlproject/IntelOwl/blob/master/api_app/helpers.py
+var (
+	urlRegex = regexp.MustCompile(`^.+://[a-z\d-]{1,200}` +
+		`(?:\.[a-zA-Z\d\x{2044}\x{2215}!#$&(-;=?-Z_a-z~]{0,200})+` +
+		`(?::\d{2,6})?` +
+		`(?:/[a-zA-Z\d\x{2044}\x{2215}!#$&(-;=?-Z_a-z~]{0,200})*` +
+		`(?:\.\w+)?`)
+	domainRegex = regexp.MustCompile(`(?i)^(\.)?[a-z\d-]{1,63}(\.[a-z\d-]{1,63})+$`)
+	hashRegex   = regexp.MustCompile(`(?i)^[a-f\d]+$`)
+)
+
+// hashAlgorithms maps the length of the hex encoded hashes to their algorithm.
+var hashAlgorithms = map[int]string{
+	32:  MD5Hash,
+	40:  SHA1Hash,
+	64:  SHA256Hash,
+	128: SHA512Hash,
+}
+
+// ClassifyObservable returns the classification IntelOwl would give to the observable:
+// IPClassification (v4 or v6), URLClassification, DomainClassification,
+// HashClassification (md5, sha1, sha256 or sha512) or GenericClassification.
+func ClassifyObservable(observable string) string {
+	observable = strings.TrimSpace(observable)
+	switch {
+	case IPVersion(observable) != 0:
+		return IPClassification
+	case urlRegex.MatchString(observable):
+		return URLClassification
+	case domainRegex.MatchString(observable):
+		return DomainClassification
+	case HashAlgorithm(observable) != "":
+		return HashClassification
+	}
+	return GenericClassification
+}
+
+// IPVersion returns 4 or 6 if the observable is an IPv4 or an IPv6 address, 0 otherwise.
+func IPVersion(observable string) int {
+	ip := net.ParseIP(strings.TrimSpace(observable))
+	switch {
+	case ip == nil:
+		return 0
+	case ip.To4() != nil && !strings.Contains(observable, ":"):
+		return 4
+	}
+	return 6
+}
+
+// HashAlgorithm returns the algorithm of a hex encoded hash: MD5Hash, SHA1Hash, SHA256Hash or SHA512Hash.
+// It returns an empty string if the observable is not a hash.
+func HashAlgorithm(observable string) string {
+	observable = strings.TrimSpace(observable)
+	if !hashRegex.MatchString(observable) {
+		return ""
+	}
+	return hashAlgorithms[len(observable)]
+}
+
+// classified returns a copy of the params with the classification filled in when it is empty.
+func (params *ObservableAnalysisParams) classified() *ObservableAnalysisParams {
+	classifiedParams := *params
+	if classifiedParams.ObservableClassification == "" {
+		classifiedParams.ObservableClassification = ClassifyObservable(params.ObservableName)
+	}
+	return &classifiedParams
+}
+
+// observablePairs returns the [classification, observable name] pairs sent to IntelOwl:
+// the Observables, with the missing classifications filled in, followed by the ObservableNames.
+func (params *MultipleObservableAnalysisParams) observablePairs() [][]string {
+	pairs := make([][]string, 0, len(params.Observables)+len(params.ObservableNames))
+	for _, observable := range params.Observables {
+		switch {
+		case len(observable) == 1:
+			pairs = append(pairs, []string{ClassifyObservable(observable[0]), observable[0]})
+		case len(observable) == 2 && observable[0] == "":
+			pairs = append(pairs, []string{ClassifyObservable(observable[1]), observable[1]})
+		default:
+			pairs = append(pairs, observable)
+		}
+	}
+	for _, observableName := range params.ObservableNames {
+		pairs = append(pairs, []string{ClassifyObservable(observableName), observableName})
+	}
+	return pairs
+}
+
+// classified returns a copy of the params where every observable is a classified pair.
+func (params *MultipleObservableAnalysisParams) classified() *MultipleObservableAnalysisParams {
+	classifiedParams := *params
+	classifiedParams.Observables = params.observablePairs()
+	classifiedParams.ObservableNames = nil
+	return &classifiedParams
+}
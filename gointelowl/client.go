@@ -17,10 +17,18 @@ import (
 )
 
 // IntelOwlError represents an error that has occurred when communicating with IntelOwl.
+// Message is the raw response body while Detail, Errors and FieldErrors are parsed from
+// the Django REST Framework error payload, if the body is one.
 type IntelOwlError struct {
 	StatusCode int
 	Message    string
 	Response   *http.Response
+	// Detail is the "detail" message e.g: {"detail": "Not found."}
+	Detail string
+	// Errors are the messages that do not belong to a field e.g: {"errors": ["..."]}
+	Errors []string
+	// FieldErrors are the validation messages of every field e.g: {"observable_name": ["This field is required."]}
+	FieldErrors map[string][]string
 }
 
 // Error lets you implement the error interface.
@@ -32,16 +40,22 @@ func (intelOwlError *IntelOwlError) Error() string {
 
 // newIntelOwlError lets you easily create new IntelOwlErrors.
 func newIntelOwlError(statusCode int, message string, response *http.Response) *IntelOwlError {
-	return &IntelOwlError{
+	intelOwlError := &IntelOwlError{
 		StatusCode: statusCode,
 		Message:    message,
 		Response:   response,
 	}
+	intelOwlError.parseMessage()
+	return intelOwlError
 }
 
-type successResponse struct {
+// SuccessResponse represents a successful response sent by IntelOwl.
+type SuccessResponse struct {
 	StatusCode int
 	Data       []byte
+	// Body is the unread response body of a streamed Call, Data is nil then.
+	// Whoever receives it must close it.
+	Body io.ReadCloser
 }
 
 // IntelOwlClientOptions represents the fields needed to configure and use the IntelOwlClient
@@ -49,9 +63,24 @@ type IntelOwlClientOptions struct {
 	Url   string `json:"url"`
 	Token string `json:"token"`
 	// Certificate represents your SSL cert: path to the cert file!
+	// It is used as the CA bundle trusted when verifying your IntelOwl instance.
 	Certificate string `json:"certificate"`
+	// ClientCertificate and ClientKey are the paths to the PEM encoded certificate and key used for mutual TLS
+	ClientCertificate string `json:"client_certificate"`
+	ClientKey         string `json:"client_key"`
+	// MinTLSVersion is the minimum TLS version accepted: "1.0", "1.1", "1.2" (default) or "1.3"
+	MinTLSVersion string `json:"min_tls_version"`
+	// PinnedPublicKeys are base64 encoded SHA-256 hashes of the SubjectPublicKeyInfo (SPKI) of your instance's certificates.
+	// The connection is refused if none of the certificates sent by the server matches a pin.
+	PinnedPublicKeys []string `json:"pinned_public_keys"`
 	// Timeout is in seconds
 	Timeout uint64 `json:"timeout"`
+	// RetryPolicy configures how transient failures are retried, nil means no retries
+	RetryPolicy *RetryPolicy `json:"retry_policy"`
+	// RateLimit limits every request sent by the client, nil means no limits
+	RateLimit *RateLimit `json:"rate_limit"`
+	// ServiceRateLimits limits the requests sent by a single service, keyed by service name e.g: AnalysisServiceName
+	ServiceRateLimits map[string]*RateLimit `json:"service_rate_limits"`
 }
 
 // IntelOwlClient handles all the communication with your IntelOwl instance.
@@ -64,6 +93,39 @@ type IntelOwlClient struct {
 	ConnectorService *ConnectorService
 	UserService      *UserService
 	Logger           *IntelOwlLogger
+	limiters         *limiterSet
+	tracer           *requestTracer
+	middlewares      *middlewareChain
+	// configurationError is returned by every request when the client could not be configured
+	configurationError error
+}
+
+// Names of the services sending requests to IntelOwl.
+// AnalysisServiceName groups the analysis methods of the IntelOwlClient.
+const (
+	TagServiceName       = "TagService"
+	JobServiceName       = "JobService"
+	AnalyzerServiceName  = "AnalyzerService"
+	ConnectorServiceName = "ConnectorService"
+	UserServiceName      = "UserService"
+	AnalysisServiceName  = "AnalysisService"
+)
+
+// operationContextKey is the context key holding the name of the operation that built a request e.g: "JobService.Get".
+type operationContextKey struct{}
+
+// operationFromContext returns the name of the operation that built the request.
+func operationFromContext(ctx context.Context) string {
+	operation, _ := ctx.Value(operationContextKey{}).(string)
+	return operation
+}
+
+// serviceOfOperation returns the name of the service an operation belongs to e.g: "JobService" for "JobService.Get".
+func serviceOfOperation(operation string) string {
+	if index := strings.Index(operation, "."); index >= 0 {
+		return operation[:index]
+	}
+	return operation
 }
 
 // TLP represents an enum for the TLP attribute used in IntelOwl's REST API.
@@ -80,8 +142,10 @@ const (
 )
 
 // TLPVALUES represents a map to easily access the TLP values.
+// CLEAR is the TLP 2.0 name of WHITE.
 var TLPVALUES = map[string]int{
 	"WHITE": 1,
+	"CLEAR": 1,
 	"GREEN": 2,
 	"AMBER": 3,
 	"RED":   4,
@@ -102,9 +166,9 @@ func (tlp TLP) String() string {
 	return "WHITE"
 }
 
-// ParseTLP is used to easily make a TLP enum
+// ParseTLP is used to easily make a TLP enum, it returns 0 for unknown values.
 func ParseTLP(s string) TLP {
-	s = strings.TrimSpace(s)
+	s = strings.ToUpper(strings.TrimSpace(s))
 	value, ok := TLPVALUES[s]
 	if !ok {
 		return TLP(0)
@@ -112,24 +176,60 @@ func ParseTLP(s string) TLP {
 	return TLP(value)
 }
 
+// IsValid reports whether the TLP is one of WHITE, GREEN, AMBER or RED.
+func (tlp TLP) IsValid() bool {
+	return tlp >= WHITE && tlp <= RED
+}
+
+// Compare returns -1, 0 or 1 if the TLP is less restrictive than, as restrictive as or more restrictive than the other.
+// e.g: GREEN.Compare(AMBER) is -1.
+func (tlp TLP) Compare(other TLP) int {
+	switch {
+	case tlp < other:
+		return -1
+	case tlp > other:
+		return 1
+	}
+	return 0
+}
+
+// IsMoreRestrictiveThan reports whether data marked with the TLP has to be shared with fewer people than the other.
+func (tlp TLP) IsMoreRestrictiveThan(other TLP) bool {
+	return tlp.Compare(other) > 0
+}
+
+// AllowedBy reports whether data marked with the TLP can go to a place accepting up to the maximum TLP.
+// Invalid TLPs are never allowed.
+func (tlp TLP) AllowedBy(maximum TLP) bool {
+	return tlp.IsValid() && maximum.IsValid() && tlp.Compare(maximum) <= 0
+}
+
 // Implementing the MarshalJSON interface to make our custom Marshal for the enum
 func (tlp TLP) MarshalJSON() ([]byte, error) {
 	return json.Marshal(tlp.String())
 }
 
-// Implementing the UnmarshalJSON interface to make our custom Unmarshal for the enum
+// Implementing the UnmarshalJSON interface to make our custom Unmarshal for the enum.
+// Unknown values are rejected while null leaves the TLP as it is.
 func (tlp *TLP) UnmarshalJSON(data []byte) (err error) {
+	if string(data) == "null" {
+		return nil
+	}
 	var tlpString string
 	if err := json.Unmarshal(data, &tlpString); err != nil {
 		return err
 	}
-	if *tlp = ParseTLP(tlpString); err != nil {
-		return err
+	parsedTlp := ParseTLP(tlpString)
+	if !parsedTlp.IsValid() {
+		return fmt.Errorf("Unknown TLP %q: use WHITE (or CLEAR), GREEN, AMBER or RED", tlpString)
 	}
+	*tlp = parsedTlp
 	return nil
 }
 
 // NewIntelOwlClient lets you easily create a new IntelOwlClient by providing IntelOwlClientOptions, http.Clients, and LoggerParams.
+// If no http.Client is passed, one is made with the TLS settings of the IntelOwlClientOptions.
+// If those settings are invalid every request returns the configuration error: use Err to check it.
 func NewIntelOwlClient(options *IntelOwlClientOptions, httpClient *http.Client, loggerParams *LoggerParams) IntelOwlClient {
 
 	var timeout time.Duration
@@ -141,16 +241,18 @@ func NewIntelOwlClient(options *IntelOwlClientOptions, httpClient *http.Client,
 	}
 
 	// configuring the http.Client
+	var configurationError error
 	if httpClient == nil {
-		httpClient = &http.Client{
-			Timeout: timeout,
-		}
+		httpClient, configurationError = newHTTPClient(options, timeout)
 	}
 
 	// configuring the client
 	client := IntelOwlClient{
-		options: options,
-		client:  httpClient,
+		options:            options,
+		client:             httpClient,
+		limiters:           newLimiterSet(options.RateLimit, options.ServiceRateLimits),
+		middlewares:        &middlewareChain{},
+		configurationError: configurationError,
 	}
 
 	// Adding the services
@@ -173,6 +275,7 @@ func NewIntelOwlClient(options *IntelOwlClientOptions, httpClient *http.Client,
 	// configuring the logger!
 	client.Logger = &IntelOwlLogger{}
 	client.Logger.Init(loggerParams)
+	client.tracer = newRequestTracer(loggerParams, client.Logger, options.Token)
 
 	return client
 }
@@ -192,12 +295,25 @@ func NewIntelOwlClientThroughJsonFile(filePath string, httpClient *http.Client,
 	}
 
 	intelOwlClient := NewIntelOwlClient(intelOwlClientOptions, httpClient, loggerParams)
+	if err := intelOwlClient.Err(); err != nil {
+		return nil, err
+	}
 
 	return &intelOwlClient, nil
 }
 
+// Err returns the error that occurred while configuring the IntelOwlClient, if any.
+func (client *IntelOwlClient) Err() error {
+	return client.configurationError
+}
+
 // buildRequest is used for building requests.
-func (client *IntelOwlClient) buildRequest(ctx context.Context, method string, contentType string, body io.Reader, url string) (*http.Request, error) {
+// operation is the name of the method building the request e.g: "JobService.Get".
+func (client *IntelOwlClient) buildRequest(ctx context.Context, operation string, method string, contentType string, body io.Reader, url string) (*http.Request, error) {
+	if client.configurationError != nil {
+		return nil, client.configurationError
+	}
+	ctx = context.WithValue(ctx, operationContextKey{}, operation)
 	request, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
@@ -211,11 +327,81 @@ func (client *IntelOwlClient) buildRequest(ctx context.Context, method string, c
 }
 
 // newRequest is used for making requests.
-func (client *IntelOwlClient) newRequest(ctx context.Context, request *http.Request) (*successResponse, error) {
+// The request goes through the client's middlewares before being sent.
+func (client *IntelOwlClient) newRequest(ctx context.Context, request *http.Request) (*SuccessResponse, error) {
+	call := &Call{
+		Operation: operationFromContext(request.Context()),
+		Request:   request,
+	}
+	handler := client.middlewares.wrap(client.sendCall)
+	defer closeRequestBody(request)
+	return handler(ctx, call)
+}
+
+// newStreamRequest is used for making requests whose response body is too big to be read in memory.
+// The SuccessResponse holds the unread Body which must be closed.
+func (client *IntelOwlClient) newStreamRequest(ctx context.Context, request *http.Request) (*SuccessResponse, error) {
+	call := &Call{
+		Operation: operationFromContext(request.Context()),
+		Request:   request,
+		Stream:    true,
+	}
+	handler := client.middlewares.wrap(client.sendCall)
+	defer closeRequestBody(request)
+	return handler(ctx, call)
+}
+
+// sendCall sends the request of a Call.
+// Every attempt waits for the client's rate limits, a streamed response holding its slot until its Body is closed, and
+// requests that fail because of transient errors are retried as configured by the client's RetryPolicy.
+func (client *IntelOwlClient) sendCall(ctx context.Context, call *Call) (*SuccessResponse, error) {
+	request := call.Request
+	retryPolicy := client.options.RetryPolicy
+	service := serviceOfOperation(call.Operation)
+	for attempt := 1; ; attempt++ {
+		release, err := client.limiters.acquire(ctx, service)
+		if err != nil {
+			closeRequestBody(request)
+			return nil, err
+		}
+		sucessResp, err := client.sendRequest(ctx, request, attempt, call.Stream)
+		if err == nil && sucessResp != nil && sucessResp.Body != nil {
+			// * a streamed response is still in flight until its body is closed
+			sucessResp.Body = &releasingBody{ReadCloser: sucessResp.Body, release: release}
+		} else {
+			release()
+		}
+		if err == nil || !retryPolicy.shouldRetry(ctx, request, attempt, err) {
+			return sucessResp, err
+		}
+		if sleepError := sleepContext(ctx, retryPolicy.delay(attempt, err)); sleepError != nil {
+			return nil, sleepError
+		}
+		retryRequest, rewindError := rewindRequest(ctx, request)
+		if rewindError != nil {
+			return nil, err
+		}
+		request = retryRequest
+	}
+}
+
+// closeRequestBody closes the body of a request that may have never been sent e.g: a middleware answered on its own.
+// Closing the body of a request that has been sent does no harm.
+func closeRequestBody(request *http.Request) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+}
+
+// sendRequest makes a single attempt at sending the request and traces it.
+// If stream is set, the body of a successful response is returned unread.
+func (client *IntelOwlClient) sendRequest(ctx context.Context, request *http.Request, attempt int, stream bool) (*SuccessResponse, error) {
+	start := time.Now()
 	response, err := client.client.Do(request)
 
 	// Checking for context errors such as reaching the deadline and/or Timeout
 	if err != nil {
+		client.tracer.trace(request, attempt, start, nil, nil, err)
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -224,9 +410,18 @@ func (client *IntelOwlClient) newRequest(ctx context.Context, request *http.Requ
 		return nil, err
 	}
 
+	if stream && response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusBadRequest {
+		client.tracer.trace(request, attempt, start, response, nil, nil)
+		return &SuccessResponse{
+			StatusCode: response.StatusCode,
+			Body:       response.Body,
+		}, nil
+	}
+
 	defer response.Body.Close()
 
 	msgBytes, err := ioutil.ReadAll(response.Body)
+	client.tracer.trace(request, attempt, start, response, msgBytes, err)
 	statusCode := response.StatusCode
 	if err != nil {
 		errorMessage := fmt.Sprintf("Could not convert JSON response. Status code: %d", statusCode)
@@ -240,7 +435,7 @@ func (client *IntelOwlClient) newRequest(ctx context.Context, request *http.Requ
 		return nil, intelOwlError
 	}
 
-	sucessResp := successResponse{
+	sucessResp := SuccessResponse{
 		StatusCode: statusCode,
 		Data:       msgBytes,
 	}
@@ -14,9 +14,44 @@ import (
 // IntelOwl docs: https://intelowl.readthedocs.io/en/latest/Usage.html#connectors-customization
 type ConnectorConfig struct {
 	BaseConfigurationType
+	// MaximumTlp is left unset (0) when IntelOwl sends a TLP unknown to the client e.g: "AMBER+STRICT"
 	MaximumTlp TLP `json:"maximum_tlp"`
 }
 
+// lenientTLP decodes the maximum TLP of a connector: the values unknown to the client leave it unset
+// instead of failing every connector configuration.
+type lenientTLP TLP
+
+// UnmarshalJSON decodes the TLP like TLP.UnmarshalJSON, without its errors.
+func (tlp *lenientTLP) UnmarshalJSON(data []byte) error {
+	if err := (*TLP)(tlp).UnmarshalJSON(data); err != nil {
+		*tlp = lenientTLP(0)
+	}
+	return nil
+}
+
+// connectorConfigAlias has the fields of ConnectorConfig without its JSON methods.
+type connectorConfigAlias ConnectorConfig
+
+// connectorConfigJSON is the JSON form of a ConnectorConfig, with its maximum TLP decoded leniently.
+type connectorConfigJSON struct {
+	*connectorConfigAlias
+	MaximumTlp lenientTLP `json:"maximum_tlp"`
+}
+
+// UnmarshalJSON decodes the connector configuration, leaving an unknown maximum TLP unset.
+func (connectorConfig *ConnectorConfig) UnmarshalJSON(data []byte) error {
+	connectorConfigJson := connectorConfigJSON{
+		connectorConfigAlias: (*connectorConfigAlias)(connectorConfig),
+		MaximumTlp:           lenientTLP(connectorConfig.MaximumTlp),
+	}
+	if err := json.Unmarshal(data, &connectorConfigJson); err != nil {
+		return err
+	}
+	connectorConfig.MaximumTlp = TLP(connectorConfigJson.MaximumTlp)
+	return nil
+}
+
 // ConnectorService handles communication with connector related methods of the IntelOwl API.
 //
 // IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/connector
@@ -33,7 +68,7 @@ func (connectorService *ConnectorService) GetConfigs(ctx context.Context) (*[]Co
 	requestUrl := connectorService.client.options.Url + constants.CONNECTOR_CONFIG_URL
 	contentType := "application/json"
 	method := "GET"
-	request, err := connectorService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := connectorService.client.buildRequest(ctx, "ConnectorService.GetConfigs", method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +107,7 @@ func (connectorService *ConnectorService) HealthCheck(ctx context.Context, conne
 	requestUrl := fmt.Sprintf(route, connectorName)
 	contentType := "application/json"
 	method := "GET"
-	request, err := connectorService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := connectorService.client.buildRequest(ctx, "ConnectorService.HealthCheck", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,374 @@
+package gointelowl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind represents how a value changed between two reports.
+type ChangeKind string
+
+// Values of the ChangeKind enum.
+const (
+	ValueAdded   ChangeKind = "added"
+	ValueRemoved ChangeKind = "removed"
+	ValueChanged ChangeKind = "changed"
+)
+
+// ValueChange represents a value that changed between two reports.
+type ValueChange struct {
+	// Path locates the value in the report e.g: "data.attributes.reputation" or "resolutions.0"
+	Path string      `json:"path"`
+	Kind ChangeKind  `json:"kind"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// ReportDiff represents what changed in the report of an analyzer or connector that ran in both jobs.
+type ReportDiff struct {
+	Name             string        `json:"name"`
+	Type             string        `json:"type"`
+	OldStatus        ReportStatus  `json:"old_status"`
+	NewStatus        ReportStatus  `json:"new_status"`
+	ErrorsIntroduced []string      `json:"errors_introduced"`
+	ErrorsResolved   []string      `json:"errors_resolved"`
+	Changes          []ValueChange `json:"changes"`
+}
+
+// StatusChanged reports whether the status of the report changed.
+func (reportDiff *ReportDiff) StatusChanged() bool {
+	return reportDiff.OldStatus != reportDiff.NewStatus
+}
+
+// HasChanges reports whether anything changed in the report.
+func (reportDiff *ReportDiff) HasChanges() bool {
+	return reportDiff.StatusChanged() || len(reportDiff.ErrorsIntroduced) > 0 || len(reportDiff.ErrorsResolved) > 0 || len(reportDiff.Changes) > 0
+}
+
+// JobDiff represents what changed between two jobs e.g: two analyses of the same observable, days apart.
+// It marshals to JSON for machines while Text renders it for humans.
+type JobDiff struct {
+	OldJobID          int       `json:"old_job_id"`
+	NewJobID          int       `json:"new_job_id"`
+	OldStatus         JobStatus `json:"old_status"`
+	NewStatus         JobStatus `json:"new_status"`
+	AnalyzersAdded    []string  `json:"analyzers_added"`
+	AnalyzersRemoved  []string  `json:"analyzers_removed"`
+	ConnectorsAdded   []string  `json:"connectors_added"`
+	ConnectorsRemoved []string  `json:"connectors_removed"`
+	// ErrorsIntroduced and ErrorsResolved are the errors of the jobs themselves, the ones of the reports are in Reports
+	ErrorsIntroduced []string `json:"errors_introduced"`
+	ErrorsResolved   []string `json:"errors_resolved"`
+	// Reports are the reports that changed, analyzers first, sorted by name
+	Reports []ReportDiff `json:"reports"`
+}
+
+// StatusChanged reports whether the status of the job changed.
+func (jobDiff *JobDiff) StatusChanged() bool {
+	return jobDiff.OldStatus != jobDiff.NewStatus
+}
+
+// HasChanges reports whether anything changed between the jobs.
+func (jobDiff *JobDiff) HasChanges() bool {
+	return jobDiff.StatusChanged() ||
+		len(jobDiff.AnalyzersAdded) > 0 || len(jobDiff.AnalyzersRemoved) > 0 ||
+		len(jobDiff.ConnectorsAdded) > 0 || len(jobDiff.ConnectorsRemoved) > 0 ||
+		len(jobDiff.ErrorsIntroduced) > 0 || len(jobDiff.ErrorsResolved) > 0 ||
+		len(jobDiff.Reports) > 0
+}
+
+// DiffOptions represents the fields to configure DiffJobs.
+//
+// Paths are made of keys and list indexes separated by dots, "*" matching any one of them
+// and "**" any number of them e.g: "data.attributes.last_analysis_date", "items.*.scan_id" or "**.timestamp".
+// A dot or a backslash that is part of a key is escaped with a backslash e.g: `https://evil\.com.*.match`.
+type DiffOptions struct {
+	// IgnorePaths are ignored in every report
+	IgnorePaths []string
+	// ReportIgnorePaths are ignored in the reports of the given analyzer or connector only
+	ReportIgnorePaths map[string][]string
+}
+
+// ignored reports whether the changes at the path of the report are ignored.
+func (diffOptions *DiffOptions) ignored(reportName string, path []string) bool {
+	if diffOptions == nil {
+		return false
+	}
+	for _, patterns := range [][]string{diffOptions.IgnorePaths, diffOptions.ReportIgnorePaths[reportName]} {
+		for _, pattern := range patterns {
+			if matchPath(splitPattern(pattern), path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitPattern splits an ignored path into its segments, at the dots that are not escaped with a backslash.
+func splitPattern(pattern string) []string {
+	segments := []string{}
+	var segment strings.Builder
+	for index := 0; index < len(pattern); index++ {
+		switch {
+		case pattern[index] == '\\' && index+1 < len(pattern):
+			index++
+			segment.WriteByte(pattern[index])
+		case pattern[index] == '.':
+			segments = append(segments, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(pattern[index])
+		}
+	}
+	return append(segments, segment.String())
+}
+
+// matchPath reports whether the path matches the pattern's segments.
+func matchPath(pattern []string, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for skipped := 0; skipped <= len(path); skipped++ {
+			if matchPath(pattern[1:], path[skipped:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 || (pattern[0] != "*" && pattern[0] != path[0]) {
+		return false
+	}
+	return matchPath(pattern[1:], path[1:])
+}
+
+// DiffJobs compares two jobs, usually an older and a newer analysis of the same observable or file.
+// The options (nil means none) let you ignore the values that change at every run, such as timestamps and scan IDs.
+func DiffJobs(oldJob *Job, newJob *Job, options *DiffOptions) *JobDiff {
+	jobDiff := &JobDiff{
+		OldJobID:  oldJob.ID,
+		NewJobID:  newJob.ID,
+		OldStatus: oldJob.Status,
+		NewStatus: newJob.Status,
+		Reports:   []ReportDiff{},
+	}
+	jobDiff.ErrorsIntroduced, jobDiff.ErrorsResolved = diffStrings(oldJob.Errors, newJob.Errors)
+	jobDiff.AnalyzersAdded, jobDiff.AnalyzersRemoved = diffReports(oldJob.AnalyzerReports, newJob.AnalyzerReports, "analyzer", options, &jobDiff.Reports)
+	jobDiff.ConnectorsAdded, jobDiff.ConnectorsRemoved = diffReports(oldJob.ConnectorReports, newJob.ConnectorReports, "connector", options, &jobDiff.Reports)
+	return jobDiff
+}
+
+// diffReports compares the reports of the plugins of a type, returning the plugins added and removed.
+func diffReports(oldReports []Report, newReports []Report, pluginType string, options *DiffOptions, reportDiffs *[]ReportDiff) ([]string, []string) {
+	oldReportsByName := map[string]*Report{}
+	for index := range oldReports {
+		oldReportsByName[oldReports[index].Name] = &oldReports[index]
+	}
+	newReportsByName := map[string]*Report{}
+	for index := range newReports {
+		newReportsByName[newReports[index].Name] = &newReports[index]
+	}
+	names := []string{}
+	added := []string{}
+	removed := []string{}
+	for name := range newReportsByName {
+		names = append(names, name)
+		if oldReportsByName[name] == nil {
+			added = append(added, name)
+		}
+	}
+	for name := range oldReportsByName {
+		if newReportsByName[name] == nil {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(added)
+	sort.Strings(removed)
+	for _, name := range names {
+		oldReport, newReport := oldReportsByName[name], newReportsByName[name]
+		if oldReport == nil {
+			continue
+		}
+		reportDiff := ReportDiff{
+			Name:      name,
+			Type:      pluginType,
+			OldStatus: oldReport.Status,
+			NewStatus: newReport.Status,
+			Changes:   []ValueChange{},
+		}
+		reportDiff.ErrorsIntroduced, reportDiff.ErrorsResolved = diffStrings(oldReport.Errors, newReport.Errors)
+		diffValues(interface{}(oldReport.Report), interface{}(newReport.Report), []string{}, func(path []string) bool {
+			return options.ignored(name, path)
+		}, &reportDiff.Changes)
+		if reportDiff.HasChanges() {
+			*reportDiffs = append(*reportDiffs, reportDiff)
+		}
+	}
+	return added, removed
+}
+
+// diffStrings returns the strings that are only in the new slice and the ones that are only in the old slice.
+func diffStrings(oldStrings []string, newStrings []string) ([]string, []string) {
+	introduced := []string{}
+	for _, value := range newStrings {
+		if !containsString(oldStrings, value) {
+			introduced = append(introduced, value)
+		}
+	}
+	resolved := []string{}
+	for _, value := range oldStrings {
+		if !containsString(newStrings, value) {
+			resolved = append(resolved, value)
+		}
+	}
+	return introduced, resolved
+}
+
+// diffValues walks two decoded JSON values side by side and records where they differ.
+func diffValues(oldValue interface{}, newValue interface{}, path []string, ignored func(path []string) bool, changes *[]ValueChange) {
+	if ignored(path) {
+		return
+	}
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := []string{}
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range newMap {
+			if _, ok := oldMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := appendPath(path, key)
+			oldChild, inOld := oldMap[key]
+			newChild, inNew := newMap[key]
+			switch {
+			case !inOld:
+				recordChange(childPath, ValueAdded, nil, newChild, ignored, changes)
+			case !inNew:
+				recordChange(childPath, ValueRemoved, oldChild, nil, ignored, changes)
+			default:
+				diffValues(oldChild, newChild, childPath, ignored, changes)
+			}
+		}
+		return
+	}
+	oldSlice, oldIsSlice := oldValue.([]interface{})
+	newSlice, newIsSlice := newValue.([]interface{})
+	if oldIsSlice && newIsSlice {
+		length := len(oldSlice)
+		if len(newSlice) > length {
+			length = len(newSlice)
+		}
+		for index := 0; index < length; index++ {
+			childPath := appendPath(path, strconv.Itoa(index))
+			switch {
+			case index >= len(oldSlice):
+				recordChange(childPath, ValueAdded, nil, newSlice[index], ignored, changes)
+			case index >= len(newSlice):
+				recordChange(childPath, ValueRemoved, oldSlice[index], nil, ignored, changes)
+			default:
+				diffValues(oldSlice[index], newSlice[index], childPath, ignored, changes)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(oldValue, newValue) {
+		recordChange(path, ValueChanged, oldValue, newValue, ignored, changes)
+	}
+}
+
+// recordChange records a change unless its path is ignored.
+func recordChange(path []string, kind ChangeKind, oldValue interface{}, newValue interface{}, ignored func(path []string) bool, changes *[]ValueChange) {
+	if ignored(path) {
+		return
+	}
+	*changes = append(*changes, ValueChange{
+		Path: strings.Join(path, "."),
+		Kind: kind,
+		Old:  oldValue,
+		New:  newValue,
+	})
+}
+
+// appendPath returns a new path with the segment at its end, leaving the original path alone.
+func appendPath(path []string, segment string) []string {
+	childPath := make([]string, len(path), len(path)+1)
+	copy(childPath, path)
+	return append(childPath, segment)
+}
+
+// Text renders the differences for humans e.g:
+//
+//	Job #1 -> #2
+//	status: running -> reported_without_fails
+//	analyzers added: Yara
+//	analyzer Classic_DNS:
+//	  ~ resolutions.0: "1.2.3.4" -> "5.6.7.8"
+func (jobDiff *JobDiff) Text() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Job #%d -> #%d\n", jobDiff.OldJobID, jobDiff.NewJobID)
+	if !jobDiff.HasChanges() {
+		builder.WriteString("no changes\n")
+		return builder.String()
+	}
+	if jobDiff.StatusChanged() {
+		fmt.Fprintf(&builder, "status: %s -> %s\n", jobDiff.OldStatus, jobDiff.NewStatus)
+	}
+	writeList := func(label string, values []string) {
+		if len(values) > 0 {
+			fmt.Fprintf(&builder, "%s: %s\n", label, strings.Join(values, ", "))
+		}
+	}
+	writeList("analyzers added", jobDiff.AnalyzersAdded)
+	writeList("analyzers removed", jobDiff.AnalyzersRemoved)
+	writeList("connectors added", jobDiff.ConnectorsAdded)
+	writeList("connectors removed", jobDiff.ConnectorsRemoved)
+	for _, jobError := range jobDiff.ErrorsIntroduced {
+		fmt.Fprintf(&builder, "error introduced: %s\n", jobError)
+	}
+	for _, jobError := range jobDiff.ErrorsResolved {
+		fmt.Fprintf(&builder, "error resolved: %s\n", jobError)
+	}
+	for _, reportDiff := range jobDiff.Reports {
+		fmt.Fprintf(&builder, "%s %s:\n", reportDiff.Type, reportDiff.Name)
+		if reportDiff.StatusChanged() {
+			fmt.Fprintf(&builder, "  status: %s -> %s\n", reportDiff.OldStatus, reportDiff.NewStatus)
+		}
+		for _, reportError := range reportDiff.ErrorsIntroduced {
+			fmt.Fprintf(&builder, "  error introduced: %s\n", reportError)
+		}
+		for _, reportError := range reportDiff.ErrorsResolved {
+			fmt.Fprintf(&builder, "  error resolved: %s\n", reportError)
+		}
+		for _, change := range reportDiff.Changes {
+			switch change.Kind {
+			case ValueAdded:
+				fmt.Fprintf(&builder, "  + %s: %s\n", change.Path, renderValue(change.New))
+			case ValueRemoved:
+				fmt.Fprintf(&builder, "  - %s: %s\n", change.Path, renderValue(change.Old))
+			default:
+				fmt.Fprintf(&builder, "  ~ %s: %s -> %s\n", change.Path, renderValue(change.Old), renderValue(change.New))
+			}
+		}
+	}
+	return builder.String()
+}
+
+// renderValue renders a value as compact JSON.
+func renderValue(value interface{}) string {
+	valueJson, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(valueJson)
+}
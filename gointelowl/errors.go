@@ -0,0 +1,150 @@
+package gointelowl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Sentinel errors matching an IntelOwlError through errors.Is.
+var (
+	// ErrNotFound matches the 404 responses
+	ErrNotFound = errors.New("Not found")
+	// ErrUnauthorized matches the 401 and 403 responses
+	ErrUnauthorized = errors.New("Unauthorized")
+	// ErrRateLimited matches the 429 responses
+	ErrRateLimited = errors.New("Rate limited")
+	// ErrValidation matches the 400 responses
+	ErrValidation = errors.New("Validation failed")
+)
+
+// Is lets errors.Is match an IntelOwlError with the sentinel error of its kind.
+func (intelOwlError *IntelOwlError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return intelOwlError.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return intelOwlError.StatusCode == http.StatusUnauthorized || intelOwlError.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return intelOwlError.StatusCode == http.StatusTooManyRequests
+	case ErrValidation:
+		return intelOwlError.StatusCode == http.StatusBadRequest
+	}
+	return false
+}
+
+// IsNotFound reports whether the error is an IntelOwlError of a resource that does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized reports whether the error is an IntelOwlError of a request that was not authenticated or not allowed.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsRateLimited reports whether the error is an IntelOwlError of a throttled request.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsValidation reports whether the error is an IntelOwlError of a request IntelOwl refused as invalid.
+func IsValidation(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
+
+// parseMessage fills Detail, Errors and FieldErrors from the Django REST Framework error payload in Message.
+// Messages that are not JSON are left as they are.
+func (intelOwlError *IntelOwlError) parseMessage() {
+	var payload interface{}
+	if err := json.Unmarshal([]byte(intelOwlError.Message), &payload); err != nil {
+		return
+	}
+	switch value := payload.(type) {
+	case map[string]interface{}:
+		intelOwlError.parseObject("", value)
+	default:
+		intelOwlError.Errors = append(intelOwlError.Errors, errorMessages(value)...)
+	}
+}
+
+// parseObject parses a JSON object of the error payload:
+// "detail" goes to Detail, "message", "errors", "error" and "non_field_errors" go to Errors and every other key is a field.
+func (intelOwlError *IntelOwlError) parseObject(prefix string, object map[string]interface{}) {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := object[key]
+		switch {
+		case prefix == "" && key == "detail":
+			messages := errorMessages(value)
+			if len(messages) == 1 {
+				intelOwlError.Detail = messages[0]
+			} else {
+				intelOwlError.Errors = append(intelOwlError.Errors, messages...)
+			}
+		case prefix == "" && (key == "errors" || key == "error" || key == "message"):
+			if nestedObject, ok := value.(map[string]interface{}); ok {
+				intelOwlError.parseObject(prefix, nestedObject)
+			} else {
+				intelOwlError.Errors = append(intelOwlError.Errors, errorMessages(value)...)
+			}
+		case key == "non_field_errors":
+			intelOwlError.Errors = append(intelOwlError.Errors, errorMessages(value)...)
+		default:
+			field := key
+			if prefix != "" {
+				field = prefix + "." + key
+			}
+			if nestedObject, ok := value.(map[string]interface{}); ok {
+				intelOwlError.parseObject(field, nestedObject)
+				continue
+			}
+			messages := errorMessages(value)
+			if len(messages) == 0 {
+				continue
+			}
+			if intelOwlError.FieldErrors == nil {
+				intelOwlError.FieldErrors = map[string][]string{}
+			}
+			intelOwlError.FieldErrors[field] = append(intelOwlError.FieldErrors[field], messages...)
+		}
+	}
+}
+
+// errorMessages flattens a JSON value of the error payload into a list of messages.
+func errorMessages(value interface{}) []string {
+	switch typedValue := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{typedValue}
+	case []interface{}:
+		messages := []string{}
+		for _, item := range typedValue {
+			messages = append(messages, errorMessages(item)...)
+		}
+		return messages
+	case map[string]interface{}:
+		messages := []string{}
+		keys := make([]string, 0, len(typedValue))
+		for key := range typedValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, message := range errorMessages(typedValue[key]) {
+				messages = append(messages, fmt.Sprintf("%s: %s", key, message))
+			}
+		}
+		return messages
+	default:
+		// * numbers and booleans are not messages
+		return nil
+	}
+}
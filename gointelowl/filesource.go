@@ -0,0 +1,67 @@
+package gointelowl
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileSource represents the content of a file to analyze, wherever it comes from:
+// a file on disk, a byte slice or any io.Reader such as an email attachment or an object store download.
+type FileSource struct {
+	// Name is the file name sent to IntelOwl
+	Name string
+	// Reader is the content of the file
+	Reader io.Reader
+	// Size is the exact size of the content in bytes, 0 when unknown.
+	// When the size of every file is known the upload is sent with a Content-Length.
+	Size int64
+	// ContentType is the MIME type of the content, defaults to application/octet-stream
+	ContentType string
+}
+
+// NewFileSourceFromFile makes a FileSource out of an *os.File, the content is read from the file's current offset.
+func NewFileSourceFromFile(file *os.File) *FileSource {
+	fileSource := &FileSource{
+		Name:   filepath.Base(file.Name()),
+		Reader: file,
+	}
+	if offset, err := file.Seek(0, io.SeekCurrent); err == nil {
+		if fileInfo, err := file.Stat(); err == nil && fileInfo.Mode().IsRegular() {
+			fileSource.Size = fileInfo.Size() - offset
+		}
+	}
+	return fileSource
+}
+
+// NewFileSourceFromBytes makes a FileSource out of an in-memory file.
+func NewFileSourceFromBytes(name string, content []byte) *FileSource {
+	return &FileSource{
+		Name:   name,
+		Reader: bytes.NewReader(content),
+		Size:   int64(len(content)),
+	}
+}
+
+// NewFileSourceFromReader makes a FileSource out of an io.Reader, size is 0 when unknown.
+// If the reader is also an io.Seeker the upload can be retried.
+func NewFileSourceFromReader(name string, reader io.Reader, size int64) *FileSource {
+	return &FileSource{
+		Name:   name,
+		Reader: reader,
+		Size:   size,
+	}
+}
+
+// validate checks that the FileSource can be uploaded.
+func (fileSource *FileSource) validate() error {
+	if fileSource == nil || fileSource.Reader == nil {
+		return errors.New("The file to analyze has no content")
+	}
+	if fileSource.Name == "" {
+		return errors.New("The file to analyze has no name")
+	}
+	return nil
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/intelowlproject/go-intelowl/constants"
@@ -18,7 +21,7 @@ type UserDetails struct {
 // Report represents a report generated by an IntelOwl job.
 type Report struct {
 	Name                 string                 `json:"name"`
-	Status               string                 `json:"status"`
+	Status               ReportStatus           `json:"status"`
 	Report               map[string]interface{} `json:"report"`
 	Errors               []string               `json:"errors"`
 	ProcessTime          float64                `json:"process_time"`
@@ -40,7 +43,7 @@ type BaseJob struct {
 	ObservableClassification string      `json:"observable_classification"`
 	FileName                 string      `json:"file_name"`
 	FileMimetype             string      `json:"file_mimetype"`
-	Status                   string      `json:"status"`
+	Status                   JobStatus   `json:"status"`
 	AnalyzersRequested       []string    `json:"analyzers_requested" `
 	ConnectorsRequested      []string    `json:"connectors_requested"`
 	AnalyzersToExecute       []string    `json:"analyzers_to_execute"`
@@ -64,6 +67,7 @@ type JobList struct {
 	BaseJob
 }
 
+// JobListResponse represents a page of the job list in IntelOwl.
 type JobListResponse struct {
 	Count      int       `json:"count"`
 	TotalPages int       `json:"total_pages"`
@@ -77,16 +81,94 @@ type JobService struct {
 	client *IntelOwlClient
 }
 
-// List fetches all the jobs in your IntelOwl instance.
+// JobListOptions represents the query parameters used to paginate and filter the job list.
+// Zero values are left out of the query.
+type JobListOptions struct {
+	// Page is the page number to fetch, starting from 1
+	Page int
+	// PageSize is the number of jobs in a page
+	PageSize                  int
+	Status                    JobStatus
+	Tlp                       TLP
+	ObservableName            string
+	ObservableClassification  string
+	Md5                       string
+	Tags                      []string
+	ReceivedRequestTimeAfter  *time.Time
+	ReceivedRequestTimeBefore *time.Time
+	// Ordering is the field to order the jobs by, prefix it with "-" for descending order e.g: "-received_request_time"
+	Ordering string
+}
+
+// queryValues converts the JobListOptions into URL query parameters.
+func (jobListOptions *JobListOptions) queryValues() url.Values {
+	values := url.Values{}
+	if jobListOptions == nil {
+		return values
+	}
+	if jobListOptions.Page > 0 {
+		values.Set("page", strconv.Itoa(jobListOptions.Page))
+	}
+	if jobListOptions.PageSize > 0 {
+		values.Set("page_size", strconv.Itoa(jobListOptions.PageSize))
+	}
+	if jobListOptions.Status != "" {
+		values.Set("status", jobListOptions.Status.String())
+	}
+	if jobListOptions.Tlp != TLP(0) {
+		values.Set("tlp", jobListOptions.Tlp.String())
+	}
+	if jobListOptions.ObservableName != "" {
+		values.Set("observable_name", jobListOptions.ObservableName)
+	}
+	if jobListOptions.ObservableClassification != "" {
+		values.Set("observable_classification", jobListOptions.ObservableClassification)
+	}
+	if jobListOptions.Md5 != "" {
+		values.Set("md5", jobListOptions.Md5)
+	}
+	if len(jobListOptions.Tags) > 0 {
+		values.Set("tags", strings.Join(jobListOptions.Tags, ","))
+	}
+	if jobListOptions.ReceivedRequestTimeAfter != nil {
+		values.Set("received_request_time__gte", jobListOptions.ReceivedRequestTimeAfter.UTC().Format(time.RFC3339))
+	}
+	if jobListOptions.ReceivedRequestTimeBefore != nil {
+		values.Set("received_request_time__lte", jobListOptions.ReceivedRequestTimeBefore.UTC().Format(time.RFC3339))
+	}
+	if jobListOptions.Ordering != "" {
+		values.Set("ordering", jobListOptions.Ordering)
+	}
+	return values
+}
+
+// List fetches the first page of jobs in your IntelOwl instance.
 //
 //	Endpoint: GET /api/jobs
 //
 // IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/jobs/operation/jobs_list
 func (jobService *JobService) List(ctx context.Context) (*JobListResponse, error) {
+	return jobService.list(ctx, "JobService.List", nil)
+}
+
+// ListWithOptions fetches a page of jobs in your IntelOwl instance filtered through JobListOptions.
+//
+//	Endpoint: GET /api/jobs
+//
+// IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/jobs/operation/jobs_list
+func (jobService *JobService) ListWithOptions(ctx context.Context, jobListOptions *JobListOptions) (*JobListResponse, error) {
+	return jobService.list(ctx, "JobService.ListWithOptions", jobListOptions)
+}
+
+// list fetches a page of jobs, tracing the request as the given operation.
+func (jobService *JobService) list(ctx context.Context, operation string, jobListOptions *JobListOptions) (*JobListResponse, error) {
 	requestUrl := jobService.client.options.Url + constants.BASE_JOB_URL
+	if query := jobListOptions.queryValues().Encode(); query != "" {
+		requestUrl = requestUrl + "?" + query
+	}
 	contentType := "application/json"
 	method := "GET"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, operation, method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +185,101 @@ func (jobService *JobService) List(ctx context.Context) (*JobListResponse, error
 	return &jobList, nil
 }
 
+// JobIterator lazily walks through every page of the job list.
+//
+//	iterator := client.JobService.Iterate(&gointelowl.JobListOptions{Status: gointelowl.JobStatusFailed})
+//	for iterator.Next(ctx) {
+//		job := iterator.Job()
+//	}
+//	if err := iterator.Err(); err != nil {
+//		// handle the error
+//	}
+type JobIterator struct {
+	jobService *JobService
+	options    JobListOptions
+	jobs       []JobList
+	index      int
+	lastPage   bool
+	err        error
+}
+
+// Iterate returns a JobIterator over every job matching the JobListOptions.
+// Pages are only fetched when needed, starting from JobListOptions.Page (or the first page).
+func (jobService *JobService) Iterate(jobListOptions *JobListOptions) *JobIterator {
+	options := JobListOptions{}
+	if jobListOptions != nil {
+		options = *jobListOptions
+	}
+	if options.Page < 1 {
+		options.Page = 1
+	}
+	return &JobIterator{
+		jobService: jobService,
+		options:    options,
+		index:      -1,
+	}
+}
+
+// Next advances the iterator to the next job, fetching the next page if needed.
+// It returns false once every job was visited, the context is done, or an error occurred.
+func (jobIterator *JobIterator) Next(ctx context.Context) bool {
+	if jobIterator.err != nil {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		jobIterator.err = err
+		return false
+	}
+	if jobIterator.index+1 < len(jobIterator.jobs) {
+		jobIterator.index++
+		return true
+	}
+	if jobIterator.lastPage {
+		return false
+	}
+	jobListResponse, err := jobIterator.jobService.ListWithOptions(ctx, &jobIterator.options)
+	if err != nil {
+		jobIterator.err = err
+		return false
+	}
+	jobIterator.jobs = jobListResponse.Results
+	jobIterator.index = 0
+	jobIterator.lastPage = len(jobListResponse.Results) == 0 || jobIterator.options.Page >= jobListResponse.TotalPages
+	jobIterator.options.Page++
+	return len(jobIterator.jobs) > 0
+}
+
+// Job returns the job the iterator is currently at.
+func (jobIterator *JobIterator) Job() JobList {
+	if jobIterator.index < 0 || jobIterator.index >= len(jobIterator.jobs) {
+		return JobList{}
+	}
+	return jobIterator.jobs[jobIterator.index]
+}
+
+// Err returns the error that stopped the iterator, if any.
+func (jobIterator *JobIterator) Err() error {
+	return jobIterator.err
+}
+
+// ListAll fetches every page of jobs matching the JobListOptions.
+// Prefer Iterate when going through a large number of jobs.
+//
+//	Endpoint: GET /api/jobs
+//
+// IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/jobs/operation/jobs_list
+func (jobService *JobService) ListAll(ctx context.Context, jobListOptions *JobListOptions) ([]JobList, error) {
+	jobs := []JobList{}
+	iterator := jobService.Iterate(jobListOptions)
+	for iterator.Next(ctx) {
+		jobs = append(jobs, iterator.Job())
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
 // Get fetches a specific job through its job ID.
 //
 //	Endpoint: GET /api/jobs/{jobID}
@@ -113,7 +290,7 @@ func (jobService *JobService) Get(ctx context.Context, jobId uint64) (*Job, erro
 	requestUrl := fmt.Sprintf(route, jobId)
 	contentType := "application/json"
 	method := "GET"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, "JobService.Get", method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +306,123 @@ func (jobService *JobService) Get(ctx context.Context, jobId uint64) (*Job, erro
 	return &jobResponse, nil
 }
 
+// JobError represents a job that stopped running without being reported (i.e. it failed or was killed).
+type JobError struct {
+	JobID  int
+	Status JobStatus
+	Errors []string
+}
+
+// Error lets you implement the error interface.
+func (jobError *JobError) Error() string {
+	errorMessage := fmt.Sprintf("Job %d ended with status %s", jobError.JobID, jobError.Status)
+	if len(jobError.Errors) > 0 {
+		errorMessage = fmt.Sprintf("%s: %s", errorMessage, strings.Join(jobError.Errors, "; "))
+	}
+	return errorMessage
+}
+
+// checkJobSucceeded returns a JobError if the job failed or was killed.
+func checkJobSucceeded(job *Job) error {
+	if job.Status.IsTerminal() && !job.Status.IsReported() {
+		return &JobError{
+			JobID:  job.ID,
+			Status: job.Status,
+			Errors: job.Errors,
+		}
+	}
+	return nil
+}
+
+// WaitOptions represents the fields to configure how JobService.Wait polls a job.
+// Zero values fall back to sensible defaults.
+type WaitOptions struct {
+	// InitialInterval is the delay before the second poll (default: 2 seconds)
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between two polls (default: 30 seconds)
+	MaxInterval time.Duration
+	// Multiplier is the factor the delay grows by after every poll (default: 1.5)
+	Multiplier float64
+	// Jitter is the fraction (0 to 1) of every delay that gets randomized (default: 0.1, a negative value disables it)
+	Jitter float64
+	// OnPoll is called after every successful poll with the fetched job
+	// and the reports that finished since the previous poll.
+	OnPoll func(job *Job, finishedReports []Report)
+	// MaxConcurrentWaits is the number of jobs of a batch analysis followed at once (default: 8)
+	MaxConcurrentWaits int
+}
+
+// withDefaults returns a copy of the WaitOptions with every unset field filled in.
+func (waitOptions *WaitOptions) withDefaults() WaitOptions {
+	options := WaitOptions{}
+	if waitOptions != nil {
+		options = *waitOptions
+	}
+	if options.InitialInterval <= 0 {
+		options.InitialInterval = 2 * time.Second
+	}
+	if options.MaxInterval <= 0 {
+		options.MaxInterval = 30 * time.Second
+	}
+	if options.MaxInterval < options.InitialInterval {
+		options.MaxInterval = options.InitialInterval
+	}
+	if options.Multiplier < 1 {
+		options.Multiplier = 1.5
+	}
+	if options.Jitter == 0 {
+		options.Jitter = 0.1
+	} else if options.Jitter < 0 {
+		options.Jitter = 0
+	}
+	if options.MaxConcurrentWaits <= 0 {
+		options.MaxConcurrentWaits = 8
+	}
+	return options
+}
+
+// Wait polls a job through its ID until it reaches a terminal status
+// (reported_without_fails, reported_with_fails, failed or killed) and returns the final job.
+// The delay between polls grows exponentially as configured by WaitOptions (nil uses the defaults).
+// Wait stops and returns the context's error as soon as the context is done.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) Wait(ctx context.Context, jobId uint64, waitOptions *WaitOptions) (*Job, error) {
+	options := waitOptions.withDefaults()
+	// * keeping track of the reports already handed to OnPoll
+	seenReports := map[string]bool{}
+	for poll := 1; ; poll++ {
+		job, err := jobService.Get(ctx, jobId)
+		if err != nil {
+			return nil, err
+		}
+		if options.OnPoll != nil {
+			finishedReports := []Report{}
+			for _, reports := range [][]Report{job.AnalyzerReports, job.ConnectorReports} {
+				for _, report := range reports {
+					key := report.Type + "/" + report.Name
+					if report.Status.IsTerminal() && !seenReports[key] {
+						seenReports[key] = true
+						finishedReports = append(finishedReports, report)
+					}
+				}
+			}
+			options.OnPoll(job, finishedReports)
+		}
+		if job.Status.IsTerminal() {
+			return job, nil
+		}
+		delay := backoffDelay(poll, options.InitialInterval, options.MaxInterval, options.Multiplier, options.Jitter)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
 // DownloadSample fetches the File sample with the given job through its job ID.
+// The whole sample is read in memory: use DownloadSampleTo or DownloadSampleToFile for big samples.
 //
 //	Endpoint: GET /api/jobs/{jobID}/download_sample
 //
@@ -139,7 +432,7 @@ func (jobService *JobService) DownloadSample(ctx context.Context, jobId uint64)
 	requestUrl := fmt.Sprintf(route, jobId)
 	contentType := "application/json"
 	method := "GET"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, "JobService.DownloadSample", method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +453,7 @@ func (jobService *JobService) Delete(ctx context.Context, jobId uint64) (bool, e
 	requestUrl := fmt.Sprintf(route, jobId)
 	contentType := "application/json"
 	method := "DELETE"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, "JobService.Delete", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -184,7 +477,7 @@ func (jobService *JobService) Kill(ctx context.Context, jobId uint64) (bool, err
 	requestUrl := fmt.Sprintf(route, jobId)
 	contentType := "application/json"
 	method := "PATCH"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, "JobService.Kill", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -208,7 +501,7 @@ func (jobService *JobService) KillAnalyzer(ctx context.Context, jobId uint64, an
 	requestUrl := fmt.Sprintf(route, jobId, analyzerName)
 	contentType := "application/json"
 	method := "PATCH"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, "JobService.KillAnalyzer", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -232,7 +525,7 @@ func (jobService *JobService) RetryAnalyzer(ctx context.Context, jobId uint64, a
 	requestUrl := fmt.Sprintf(route, jobId, analyzerName)
 	contentType := "application/json"
 	method := "PATCH"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, "JobService.RetryAnalyzer", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -256,7 +549,7 @@ func (jobService *JobService) KillConnector(ctx context.Context, jobId uint64, c
 	requestUrl := fmt.Sprintf(route, jobId, connectorName)
 	contentType := "application/json"
 	method := "PATCH"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, "JobService.KillConnector", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -280,7 +573,7 @@ func (jobService *JobService) RetryConnector(ctx context.Context, jobId uint64,
 	requestUrl := fmt.Sprintf(route, jobId, connectorName)
 	contentType := "application/json"
 	method := "PATCH"
-	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := jobService.client.buildRequest(ctx, "JobService.RetryConnector", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
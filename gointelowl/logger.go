@@ -7,11 +7,28 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// LogFields represents the structured fields attached to a log entry.
+type LogFields map[string]interface{}
+
+// Logger is the interface the IntelOwlClient traces its requests through.
+// IntelOwlLogger is the default, logrus based, implementation:
+// implement Logger and pass it through LoggerParams to use your own logging library.
+type Logger interface {
+	Debug(message string, fields LogFields)
+}
+
 // LoggerParams represents the fields to configure your logger.
 type LoggerParams struct {
 	File      io.Writer
 	Formatter logrus.Formatter
 	Level     logrus.Level
+	// Logger replaces the IntelOwlLogger when tracing requests
+	Logger Logger
+	// DumpBodies adds the request headers and the request and response bodies to the traces.
+	// The Authorization token is always redacted.
+	DumpBodies bool
+	// MaxDumpSize is the number of bytes of a body that are dumped (default: 4096)
+	MaxDumpSize int
 }
 
 // IntelOwlLogger represents a logger to be used by the developer.
@@ -40,3 +57,8 @@ func (intelOwlLogger *IntelOwlLogger) Init(loggerParams *LoggerParams) {
 	logger.SetLevel(loggerParams.Level)
 	intelOwlLogger.Logger = logger
 }
+
+// Debug lets you implement the Logger interface.
+func (intelOwlLogger *IntelOwlLogger) Debug(message string, fields LogFields) {
+	intelOwlLogger.Logger.WithFields(logrus.Fields(fields)).Debug(message)
+}
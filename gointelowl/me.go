@@ -79,7 +79,7 @@ func (userService *UserService) Access(ctx context.Context) (*User, error) {
 	requestUrl := userService.client.options.Url + constants.USER_DETAILS_URL
 	contentType := "application/json"
 	method := "GET"
-	request, err := userService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := userService.client.buildRequest(ctx, "UserService.Access", method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +103,7 @@ func (userService *UserService) Organization(ctx context.Context) (*Organization
 	requestUrl := userService.client.options.Url + constants.ORGANIZATION_URL
 	contentType := "application/json"
 	method := "GET"
-	request, err := userService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := userService.client.buildRequest(ctx, "UserService.Organization", method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +134,7 @@ func (userService *UserService) CreateOrganization(ctx context.Context, organiza
 	contentType := "application/json"
 	method := "POST"
 	body := bytes.NewBuffer(orgJson)
-	request, err := userService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	request, err := userService.client.buildRequest(ctx, "UserService.CreateOrganization", method, contentType, body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -166,7 +166,7 @@ func (userService *UserService) InviteToOrganization(ctx context.Context, member
 	contentType := "application/json"
 	method := "POST"
 	body := bytes.NewBuffer(memberJson)
-	request, err := userService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	request, err := userService.client.buildRequest(ctx, "UserService.InviteToOrganization", method, contentType, body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -198,7 +198,7 @@ func (userService *UserService) RemoveMemberFromOrganization(ctx context.Context
 	contentType := "application/json"
 	method := "POST"
 	body := bytes.NewBuffer(memberJson)
-	request, err := userService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	request, err := userService.client.buildRequest(ctx, "UserService.RemoveMemberFromOrganization", method, contentType, body, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,94 @@
+package gointelowl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Call represents a request going through the IntelOwlClient's middlewares.
+type Call struct {
+	// Operation is the name of the method that built the request e.g: "JobService.Get"
+	Operation string
+	// Request is the request that is going to be sent, middlewares can modify or replace it
+	Request *http.Request
+	// Stream is set when the response body is handed unread to the caller through SuccessResponse.Body
+	Stream bool
+}
+
+// Handler sends a Call to IntelOwl.
+// The error is an IntelOwlError when IntelOwl answered with an error.
+type Handler func(ctx context.Context, call *Call) (*SuccessResponse, error)
+
+// Middleware wraps a Handler to run code before and after a Call is sent.
+// A Middleware can also answer a Call on its own by not calling the next Handler.
+type Middleware func(next Handler) Handler
+
+// middlewareChain holds the middlewares of an IntelOwlClient.
+type middlewareChain struct {
+	mutex       sync.RWMutex
+	middlewares []Middleware
+}
+
+// wrap wraps the handler with every middleware, the first middleware being the outermost.
+func (chain *middlewareChain) wrap(handler Handler) Handler {
+	chain.mutex.RLock()
+	defer chain.mutex.RUnlock()
+	for index := len(chain.middlewares) - 1; index >= 0; index-- {
+		handler = chain.middlewares[index](handler)
+	}
+	return handler
+}
+
+// Use adds middlewares to the IntelOwlClient.
+// Middlewares run in the order they were added: the first one sees the Call first and the response last.
+func (client *IntelOwlClient) Use(middlewares ...Middleware) {
+	client.middlewares.mutex.Lock()
+	defer client.middlewares.mutex.Unlock()
+	client.middlewares.middlewares = append(client.middlewares.middlewares, middlewares...)
+}
+
+// UserAgentMiddleware sets the User-Agent header of every request.
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*SuccessResponse, error) {
+			call.Request.Header.Set("User-Agent", userAgent)
+			return next(ctx, call)
+		}
+	}
+}
+
+// RequestIDMiddleware sets a random request ID in the given header (X-Request-ID if empty) of every request
+// that does not have one yet. The same ID is kept across retries.
+func RequestIDMiddleware(header string) Middleware {
+	if header == "" {
+		header = "X-Request-ID"
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*SuccessResponse, error) {
+			if call.Request.Header.Get(header) == "" {
+				requestID := make([]byte, 16)
+				if _, err := rand.Read(requestID); err != nil {
+					return nil, err
+				}
+				call.Request.Header.Set(header, hex.EncodeToString(requestID))
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+// TimingMiddleware reports how long every Call took, retries and rate limiting included.
+func TimingMiddleware(report func(operation string, duration time.Duration, err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*SuccessResponse, error) {
+			start := time.Now()
+			sucessResp, err := next(ctx, call)
+			report(call.Operation, time.Since(start), err)
+			return sucessResp, err
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package gointelowl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+)
+
+// UploadProgressFunc is called while a file is uploaded with the bytes of the file sent so far.
+// total is the size of the file, -1 when it is unknown.
+// If the upload is retried the progress starts over from 0.
+type UploadProgressFunc func(fileName string, sent int64, total int64)
+
+// quoteEscaper escapes the field and file names of the Content-Disposition header, like mime/multipart does.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartField is a form field of a multipart body.
+type multipartField struct {
+	name  string
+	value string
+}
+
+// multipartFile is a file of a multipart body.
+type multipartFile struct {
+	fieldName   string
+	fileName    string
+	contentType string
+	reader      io.Reader
+	// size is -1 when unknown
+	size int64
+	// offset is where reading starts, -1 when the reader cannot be rewound
+	offset int64
+}
+
+// multipartBody streams a multipart/form-data body through an io.Pipe,
+// so the files are never held in memory no matter how big they are.
+type multipartBody struct {
+	ctx        context.Context
+	boundary   string
+	fields     []multipartField
+	files      []*multipartFile
+	onProgress UploadProgressFunc
+	// mutex guards the stream currently being written
+	mutex  sync.Mutex
+	reader *io.PipeReader
+	done   chan struct{}
+}
+
+// newMultipartBody makes an empty multipart body, writing stops as soon as the context is done.
+func newMultipartBody(ctx context.Context, onProgress UploadProgressFunc) *multipartBody {
+	return &multipartBody{
+		ctx:        ctx,
+		boundary:   multipart.NewWriter(io.Discard).Boundary(),
+		onProgress: onProgress,
+	}
+}
+
+// addField adds a form field.
+func (body *multipartBody) addField(name string, value string) {
+	body.fields = append(body.fields, multipartField{name: name, value: value})
+}
+
+// addBasicAnalysisParams adds the fields shared by every file analysis.
+func (body *multipartBody) addBasicAnalysisParams(basicAnalysisParams *BasicAnalysisParams) error {
+	body.addField("tlp", basicAnalysisParams.Tlp.String())
+	runTimeConfigurationJson, marshalError := json.Marshal(basicAnalysisParams.RuntimeConfiguration)
+	if marshalError != nil {
+		return marshalError
+	}
+	body.addField("runtime_configuration", string(runTimeConfigurationJson))
+	for _, analyzer := range basicAnalysisParams.AnalyzersRequested {
+		body.addField("analyzers_requested", analyzer)
+	}
+	for _, connector := range basicAnalysisParams.ConnectorsRequested {
+		body.addField("connectors_requested", connector)
+	}
+	for _, tagLabel := range basicAnalysisParams.TagsLabels {
+		body.addField("tags_labels", tagLabel)
+	}
+	return nil
+}
+
+// addSource adds a file, remembering the current offset of seekable readers so that it can be sent again.
+func (body *multipartBody) addSource(fieldName string, fileSource *FileSource) error {
+	if err := fileSource.validate(); err != nil {
+		return err
+	}
+	multipartFile := &multipartFile{
+		fieldName:   fieldName,
+		fileName:    fileSource.Name,
+		contentType: fileSource.ContentType,
+		reader:      fileSource.Reader,
+		size:        -1,
+		offset:      -1,
+	}
+	if fileSource.Size > 0 {
+		multipartFile.size = fileSource.Size
+	}
+	if seeker, isSeeker := fileSource.Reader.(io.Seeker); isSeeker {
+		if offset, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			multipartFile.offset = offset
+		}
+	}
+	body.files = append(body.files, multipartFile)
+	return nil
+}
+
+// addFile adds an *os.File.
+func (body *multipartBody) addFile(fieldName string, file *os.File) error {
+	if file == nil {
+		return errors.New("The file to analyze is nil")
+	}
+	return body.addSource(fieldName, NewFileSourceFromFile(file))
+}
+
+// contentType returns the Content-Type of the body, boundary included.
+func (body *multipartBody) contentType() string {
+	return "multipart/form-data; boundary=" + body.boundary
+}
+
+// write writes the whole body.
+// When counting, the content of the files is skipped and only their size is counted.
+func (body *multipartBody) write(writer io.Writer, counting *countingWriter) error {
+	multipartWriter := multipart.NewWriter(writer)
+	if err := multipartWriter.SetBoundary(body.boundary); err != nil {
+		return err
+	}
+	for _, field := range body.fields {
+		if err := multipartWriter.WriteField(field.name, field.value); err != nil {
+			return err
+		}
+	}
+	for _, file := range body.files {
+		filePart, err := multipartWriter.CreatePart(file.header())
+		if err != nil {
+			return err
+		}
+		if counting != nil {
+			counting.count += file.size
+			continue
+		}
+		progress := &progressWriter{writer: filePart, file: file, onProgress: body.onProgress}
+		if _, err := io.Copy(progress, &contextReader{ctx: body.ctx, reader: file.reader}); err != nil {
+			return err
+		}
+	}
+	return multipartWriter.Close()
+}
+
+// header makes the MIME header of the file's part.
+func (file *multipartFile) header() textproto.MIMEHeader {
+	contentType := file.contentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(file.fieldName), quoteEscaper.Replace(file.fileName)))
+	header.Set("Content-Type", contentType)
+	return header
+}
+
+// contentLength calculates the size of the body, -1 if the size of one of the files is unknown.
+func (body *multipartBody) contentLength() int64 {
+	for _, file := range body.files {
+		if file.size < 0 {
+			return -1
+		}
+	}
+	counting := &countingWriter{writer: io.Discard}
+	if err := body.write(counting, counting); err != nil {
+		return -1
+	}
+	return counting.count
+}
+
+// rewindable reports whether every file can be read again from its start.
+func (body *multipartBody) rewindable() bool {
+	for _, file := range body.files {
+		if _, isSeeker := file.reader.(io.Seeker); !isSeeker || file.offset < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// open starts streaming the body and returns the reading end of the stream.
+// Opening it again stops the previous stream and rewinds the files.
+func (body *multipartBody) open() (io.ReadCloser, error) {
+	body.mutex.Lock()
+	defer body.mutex.Unlock()
+	if body.reader != nil {
+		// * waiting for the previous stream to stop reading the files before rewinding them
+		body.reader.CloseWithError(errors.New("The multipart body has been reopened"))
+		<-body.done
+		for _, file := range body.files {
+			if _, err := file.reader.(io.Seeker).Seek(file.offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	done := make(chan struct{})
+	body.reader = pipeReader
+	body.done = done
+	go func() {
+		defer close(done)
+		pipeWriter.CloseWithError(body.write(pipeWriter, nil))
+	}()
+	return pipeReader, nil
+}
+
+// lazy returns a body that only starts streaming when it is first read, so that a request which is never sent
+// (its context is done while it waits for the rate limiter, a middleware answers on its own...) does not leave
+// a goroutine reading the files.
+func (body *multipartBody) lazy() io.ReadCloser {
+	return &lazyBody{body: body}
+}
+
+// lazyBody opens the multipart body at its first read.
+type lazyBody struct {
+	body   *multipartBody
+	mutex  sync.Mutex
+	reader io.ReadCloser
+	closed bool
+}
+
+func (lazyBody *lazyBody) Read(data []byte) (int, error) {
+	lazyBody.mutex.Lock()
+	if lazyBody.closed {
+		lazyBody.mutex.Unlock()
+		return 0, errors.New("The multipart body has been closed")
+	}
+	if lazyBody.reader == nil {
+		reader, err := lazyBody.body.open()
+		if err != nil {
+			lazyBody.mutex.Unlock()
+			return 0, err
+		}
+		lazyBody.reader = reader
+	}
+	reader := lazyBody.reader
+	lazyBody.mutex.Unlock()
+	return reader.Read(data)
+}
+
+// Close stops the stream if it has been opened, a body closed before being read is never opened.
+func (lazyBody *lazyBody) Close() error {
+	lazyBody.mutex.Lock()
+	lazyBody.closed = true
+	reader := lazyBody.reader
+	lazyBody.mutex.Unlock()
+	if reader != nil {
+		return reader.Close()
+	}
+	return nil
+}
+
+// contextReader stops reading as soon as the context is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (contextReader *contextReader) Read(data []byte) (int, error) {
+	if err := contextReader.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return contextReader.reader.Read(data)
+}
+
+// progressWriter reports the bytes of a file written to the stream.
+type progressWriter struct {
+	writer     io.Writer
+	file       *multipartFile
+	onProgress UploadProgressFunc
+	sent       int64
+}
+
+func (progressWriter *progressWriter) Write(data []byte) (int, error) {
+	written, err := progressWriter.writer.Write(data)
+	if written > 0 && progressWriter.onProgress != nil {
+		progressWriter.sent += int64(written)
+		progressWriter.onProgress(progressWriter.file.fileName, progressWriter.sent, progressWriter.file.size)
+	}
+	return written, err
+}
+
+// buildMultipartRequest builds a POST request streaming the multipart body.
+// The request can be retried only if all of the files can be rewound.
+func (client *IntelOwlClient) buildMultipartRequest(ctx context.Context, operation string, body *multipartBody, url string) (*http.Request, error) {
+	request, err := client.buildRequest(ctx, operation, http.MethodPost, body.contentType(), nil, url)
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = body.contentLength()
+	request.Body = body.lazy()
+	if body.rewindable() {
+		request.GetBody = func() (io.ReadCloser, error) {
+			return body.lazy(), nil
+		}
+	}
+	return request, nil
+}
@@ -0,0 +1,164 @@
+package gointelowl
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Types of the plugin parameters, as IntelOwl names them.
+const (
+	StringParameterType = "str"
+	IntParameterType    = "int"
+	FloatParameterType  = "float"
+	BoolParameterType   = "bool"
+	ListParameterType   = "list"
+	DictParameterType   = "dict"
+)
+
+// TypeName returns the type of the parameter e.g: "str", or an empty string if IntelOwl did not send one.
+func (parameter *Parameter) TypeName() string {
+	typeName, _ := parameter.Type.(string)
+	return typeName
+}
+
+// StringValue returns the default value of a "str" parameter.
+func (parameter *Parameter) StringValue() (string, error) {
+	value, ok := parameter.Value.(string)
+	if !ok {
+		return "", parameter.typeError(StringParameterType)
+	}
+	return value, nil
+}
+
+// IntValue returns the default value of an "int" parameter.
+func (parameter *Parameter) IntValue() (int, error) {
+	value, ok := parameter.Value.(float64)
+	if !ok || value != math.Trunc(value) {
+		return 0, parameter.typeError(IntParameterType)
+	}
+	return int(value), nil
+}
+
+// FloatValue returns the default value of a "float" or "int" parameter.
+func (parameter *Parameter) FloatValue() (float64, error) {
+	value, ok := parameter.Value.(float64)
+	if !ok {
+		return 0, parameter.typeError(FloatParameterType)
+	}
+	return value, nil
+}
+
+// BoolValue returns the default value of a "bool" parameter.
+func (parameter *Parameter) BoolValue() (bool, error) {
+	value, ok := parameter.Value.(bool)
+	if !ok {
+		return false, parameter.typeError(BoolParameterType)
+	}
+	return value, nil
+}
+
+// ListValue returns the default value of a "list" parameter.
+func (parameter *Parameter) ListValue() ([]interface{}, error) {
+	value, ok := parameter.Value.([]interface{})
+	if !ok {
+		return nil, parameter.typeError(ListParameterType)
+	}
+	return value, nil
+}
+
+// StringListValue returns the default value of a "list" parameter whose items are all strings.
+func (parameter *Parameter) StringListValue() ([]string, error) {
+	list, err := parameter.ListValue()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		value, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("The parameter holds a list of %T, not of strings", item)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// DictValue returns the default value of a "dict" parameter.
+func (parameter *Parameter) DictValue() (map[string]interface{}, error) {
+	value, ok := parameter.Value.(map[string]interface{})
+	if !ok {
+		return nil, parameter.typeError(DictParameterType)
+	}
+	return value, nil
+}
+
+// typeError describes a default value that does not have the wanted type.
+func (parameter *Parameter) typeError(wantedType string) error {
+	return fmt.Errorf("The parameter of type %s holds %s, not a value of type %s", parameter.TypeName(), describeValue(parameter.Value), wantedType)
+}
+
+// Accepts reports whether the value can be sent for the parameter, given its type.
+// Any Go value of the matching kind is accepted e.g: an int64 or a float64 with no fractional part for "int",
+// a []string for "list" or a map with string keys for "dict". Parameters of unknown type accept every value.
+func (parameter *Parameter) Accepts(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if reflectValue.IsNil() {
+			return false
+		}
+		reflectValue = reflectValue.Elem()
+	}
+	kind := reflectValue.Kind()
+	switch parameter.TypeName() {
+	case StringParameterType:
+		return kind == reflect.String
+	case IntParameterType:
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Float32, reflect.Float64:
+			return reflectValue.Float() == math.Trunc(reflectValue.Float())
+		}
+		return false
+	case FloatParameterType:
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	case BoolParameterType:
+		return kind == reflect.Bool
+	case ListParameterType:
+		return kind == reflect.Slice || kind == reflect.Array
+	case DictParameterType:
+		return (kind == reflect.Map && reflectValue.Type().Key().Kind() == reflect.String) || kind == reflect.Struct
+	}
+	return true
+}
+
+// describeValue names the JSON type of a value, for error messages.
+func describeValue(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "a dict"
+	}
+	return fmt.Sprintf("a %T", value)
+}
@@ -0,0 +1,210 @@
+package gointelowl
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimit represents the limits applied to the requests sent to IntelOwl.
+type RateLimit struct {
+	// RequestsPerSecond is the rate at which the token bucket refills (0 means no rate limit)
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// Burst is the size of the token bucket i.e. how many requests can be sent at once (defaults to 1)
+	Burst int `json:"burst"`
+	// MaxInFlight caps the number of concurrent requests, streamed downloads included until they end (0 means no cap)
+	MaxInFlight int `json:"max_in_flight"`
+}
+
+// LimiterStats represents the current state of a rate limiter.
+type LimiterStats struct {
+	// Waiting is the number of requests queued behind the limiter
+	Waiting int
+	// InFlight is the number of requests currently being sent, a streamed response counting until its body is closed
+	InFlight int
+}
+
+// limiter is a token bucket combined with a semaphore capping the requests in flight.
+type limiter struct {
+	mutex    sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+	slots    chan struct{}
+	waiting  int64
+	inFlight int64
+}
+
+// newLimiter makes a limiter out of a RateLimit, nil means no limits.
+func newLimiter(rateLimit *RateLimit) *limiter {
+	if rateLimit == nil {
+		return nil
+	}
+	burst := float64(rateLimit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	newLimiter := &limiter{
+		rate:   rateLimit.RequestsPerSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+	if rateLimit.MaxInFlight > 0 {
+		newLimiter.slots = make(chan struct{}, rateLimit.MaxInFlight)
+	}
+	return newLimiter
+}
+
+// reserve takes a token out of the bucket and returns how long to wait before using it.
+func (limiter *limiter) reserve() time.Duration {
+	if limiter.rate <= 0 {
+		return 0
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	now := time.Now()
+	limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.rate
+	if limiter.tokens > limiter.burst {
+		limiter.tokens = limiter.burst
+	}
+	limiter.last = now
+	limiter.tokens--
+	if limiter.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-limiter.tokens / limiter.rate * float64(time.Second))
+}
+
+// cancelReservation gives back a token that was reserved but not used.
+func (limiter *limiter) cancelReservation() {
+	if limiter.rate <= 0 {
+		return
+	}
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.tokens++
+}
+
+// acquire waits for a token and a free slot, the returned function must be called once the request is done.
+func (limiter *limiter) acquire(ctx context.Context) (func(), error) {
+	if limiter == nil {
+		return func() {}, nil
+	}
+	atomic.AddInt64(&limiter.waiting, 1)
+	defer atomic.AddInt64(&limiter.waiting, -1)
+
+	if err := sleepContext(ctx, limiter.reserve()); err != nil {
+		limiter.cancelReservation()
+		return nil, err
+	}
+	if limiter.slots != nil {
+		select {
+		case limiter.slots <- struct{}{}:
+		case <-ctx.Done():
+			limiter.cancelReservation()
+			return nil, ctx.Err()
+		}
+	}
+	atomic.AddInt64(&limiter.inFlight, 1)
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			atomic.AddInt64(&limiter.inFlight, -1)
+			if limiter.slots != nil {
+				<-limiter.slots
+			}
+		})
+	}
+	return release, nil
+}
+
+// stats returns the current state of the limiter.
+func (limiter *limiter) stats() LimiterStats {
+	if limiter == nil {
+		return LimiterStats{}
+	}
+	return LimiterStats{
+		Waiting:  int(atomic.LoadInt64(&limiter.waiting)),
+		InFlight: int(atomic.LoadInt64(&limiter.inFlight)),
+	}
+}
+
+// releasingBody is the body of a streamed response, releasing the limiters once it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (body *releasingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.release()
+	return err
+}
+
+// limiterSet holds the client wide limiter and the limiters of every service.
+type limiterSet struct {
+	client   *limiter
+	services map[string]*limiter
+}
+
+// newLimiterSet makes the limiters configured in the IntelOwlClientOptions.
+func newLimiterSet(rateLimit *RateLimit, serviceRateLimits map[string]*RateLimit) *limiterSet {
+	limiters := &limiterSet{
+		client:   newLimiter(rateLimit),
+		services: map[string]*limiter{},
+	}
+	for service, serviceRateLimit := range serviceRateLimits {
+		if serviceLimiter := newLimiter(serviceRateLimit); serviceLimiter != nil {
+			limiters.services[service] = serviceLimiter
+		}
+	}
+	return limiters
+}
+
+// acquire waits for the service's limiter and then for the client wide limiter.
+func (limiters *limiterSet) acquire(ctx context.Context, service string) (func(), error) {
+	if limiters == nil {
+		return func() {}, nil
+	}
+	releaseService, err := limiters.services[service].acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	releaseClient, err := limiters.client.acquire(ctx)
+	if err != nil {
+		releaseService()
+		return nil, err
+	}
+	return func() {
+		releaseClient()
+		releaseService()
+	}, nil
+}
+
+// LimiterStats returns the current state of the limiter of the given service e.g: AnalysisServiceName.
+// An empty service name returns the state of the client wide limiter.
+func (client *IntelOwlClient) LimiterStats(service string) LimiterStats {
+	if client.limiters == nil {
+		return LimiterStats{}
+	}
+	if service == "" {
+		return client.limiters.client.stats()
+	}
+	return client.limiters.services[service].stats()
+}
+
+// QueueDepth returns the number of requests currently waiting behind any of the client's limiters.
+func (client *IntelOwlClient) QueueDepth() int {
+	if client.limiters == nil {
+		return 0
+	}
+	queueDepth := client.limiters.client.stats().Waiting
+	for _, serviceLimiter := range client.limiters.services {
+		queueDepth += serviceLimiter.stats().Waiting
+	}
+	return queueDepth
+}
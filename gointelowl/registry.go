@@ -0,0 +1,236 @@
+package gointelowl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultPluginRegistryTTL is how long a PluginRegistry keeps the configurations before fetching them again.
+const DefaultPluginRegistryTTL = 10 * time.Minute
+
+// ErrPluginNotFound is returned when looking up a plugin that your IntelOwl instance does not have.
+var ErrPluginNotFound = errors.New("Plugin not found")
+
+// Bool is a helper to fill in the optional booleans of the queries e.g: AnalyzerQuery{LeaksInfo: gointelowl.Bool(false)}.
+func Bool(value bool) *bool {
+	return &value
+}
+
+// AnalyzerQuery represents the filters of PluginRegistry.Analyzers.
+// Zero values do not filter.
+type AnalyzerQuery struct {
+	// ObservableType keeps the observable analyzers supporting the classification e.g: "ip"
+	ObservableType string
+	// Mimetype keeps the file analyzers supporting the mimetype e.g: "application/pdf"
+	Mimetype        string
+	ExternalService *bool
+	DockerBased     *bool
+	LeaksInfo       *bool
+	// ConfiguredOnly keeps the analyzers whose secrets and configuration are all set
+	ConfiguredOnly bool
+	// EnabledOnly keeps the analyzers that are not disabled
+	EnabledOnly bool
+}
+
+// matches reports whether the analyzer passes every filter of the query.
+func (analyzerQuery *AnalyzerQuery) matches(analyzerConfig *AnalyzerConfig) bool {
+	if analyzerQuery == nil {
+		return true
+	}
+	switch {
+	case analyzerQuery.ObservableType != "" && !analyzerConfig.SupportsObservable(analyzerQuery.ObservableType),
+		analyzerQuery.Mimetype != "" && !analyzerConfig.SupportsMimetype(analyzerQuery.Mimetype),
+		analyzerQuery.ExternalService != nil && analyzerConfig.ExternalService != *analyzerQuery.ExternalService,
+		analyzerQuery.DockerBased != nil && analyzerConfig.DockerBased != *analyzerQuery.DockerBased,
+		analyzerQuery.LeaksInfo != nil && analyzerConfig.LeaksInfo != *analyzerQuery.LeaksInfo,
+		analyzerQuery.ConfiguredOnly && !analyzerConfig.Verification.Configured,
+		analyzerQuery.EnabledOnly && analyzerConfig.Disabled:
+		return false
+	}
+	return true
+}
+
+// ConnectorQuery represents the filters of PluginRegistry.Connectors.
+// Zero values do not filter.
+type ConnectorQuery struct {
+	// AcceptsTlp keeps the connectors whose MaximumTlp allows data with the TLP
+	AcceptsTlp TLP
+	// ConfiguredOnly keeps the connectors whose secrets and configuration are all set
+	ConfiguredOnly bool
+	// EnabledOnly keeps the connectors that are not disabled
+	EnabledOnly bool
+}
+
+// matches reports whether the connector passes every filter of the query.
+func (connectorQuery *ConnectorQuery) matches(connectorConfig *ConnectorConfig) bool {
+	if connectorQuery == nil {
+		return true
+	}
+	switch {
+	case connectorQuery.AcceptsTlp != TLP(0) && !connectorQuery.AcceptsTlp.AllowedBy(connectorConfig.MaximumTlp),
+		connectorQuery.ConfiguredOnly && !connectorConfig.Verification.Configured,
+		connectorQuery.EnabledOnly && connectorConfig.Disabled:
+		return false
+	}
+	return true
+}
+
+// PluginRegistry caches the analyzer and connector configurations of your IntelOwl instance,
+// so that looking them up does not download every configuration each time.
+// The configurations are fetched on first use and again once they are older than the TTL. It is safe for concurrent use.
+type PluginRegistry struct {
+	// TTL is how long the configurations are kept, zero means until Refresh is called
+	TTL              time.Duration
+	client           *IntelOwlClient
+	mutex            sync.Mutex
+	analyzers        []AnalyzerConfig
+	connectors       []ConnectorConfig
+	analyzersByName  map[string]int
+	connectorsByName map[string]int
+	fetchedAt        time.Time
+}
+
+// NewPluginRegistry makes a PluginRegistry keeping the configurations for the given TTL e.g: DefaultPluginRegistryTTL.
+func (client *IntelOwlClient) NewPluginRegistry(ttl time.Duration) *PluginRegistry {
+	return &PluginRegistry{
+		TTL:    ttl,
+		client: client,
+	}
+}
+
+// Refresh fetches the analyzer and connector configurations right away.
+// If it fails the configurations fetched before are kept.
+//
+//	Endpoints: GET /api/get_analyzer_configs and GET /api/get_connector_configs
+func (pluginRegistry *PluginRegistry) Refresh(ctx context.Context) error {
+	pluginRegistry.mutex.Lock()
+	defer pluginRegistry.mutex.Unlock()
+	return pluginRegistry.refresh(ctx)
+}
+
+// refresh fetches the configurations, the mutex must be held.
+func (pluginRegistry *PluginRegistry) refresh(ctx context.Context) error {
+	analyzerConfigs, err := pluginRegistry.client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return err
+	}
+	connectorConfigs, err := pluginRegistry.client.ConnectorService.GetConfigs(ctx)
+	if err != nil {
+		return err
+	}
+	pluginRegistry.analyzers = *analyzerConfigs
+	pluginRegistry.connectors = *connectorConfigs
+	pluginRegistry.analyzersByName = make(map[string]int, len(pluginRegistry.analyzers))
+	for index, analyzerConfig := range pluginRegistry.analyzers {
+		pluginRegistry.analyzersByName[analyzerConfig.Name] = index
+	}
+	pluginRegistry.connectorsByName = make(map[string]int, len(pluginRegistry.connectors))
+	for index, connectorConfig := range pluginRegistry.connectors {
+		pluginRegistry.connectorsByName[connectorConfig.Name] = index
+	}
+	pluginRegistry.fetchedAt = time.Now()
+	return nil
+}
+
+// load refreshes the configurations if they are missing or expired, the mutex must be held.
+func (pluginRegistry *PluginRegistry) load(ctx context.Context) error {
+	if pluginRegistry.fetchedAt.IsZero() || (pluginRegistry.TTL > 0 && time.Since(pluginRegistry.fetchedAt) >= pluginRegistry.TTL) {
+		return pluginRegistry.refresh(ctx)
+	}
+	return nil
+}
+
+// FetchedAt returns when the configurations were last fetched, the zero time if they never were.
+func (pluginRegistry *PluginRegistry) FetchedAt() time.Time {
+	pluginRegistry.mutex.Lock()
+	defer pluginRegistry.mutex.Unlock()
+	return pluginRegistry.fetchedAt
+}
+
+// Analyzer looks up an analyzer configuration by name.
+// The returned error wraps ErrPluginNotFound if there is no such analyzer.
+func (pluginRegistry *PluginRegistry) Analyzer(ctx context.Context, analyzerName string) (*AnalyzerConfig, error) {
+	pluginRegistry.mutex.Lock()
+	defer pluginRegistry.mutex.Unlock()
+	if err := pluginRegistry.load(ctx); err != nil {
+		return nil, err
+	}
+	index, ok := pluginRegistry.analyzersByName[analyzerName]
+	if !ok {
+		return nil, fmt.Errorf("%w: there is no analyzer named %s", ErrPluginNotFound, analyzerName)
+	}
+	analyzerConfig := pluginRegistry.analyzers[index]
+	return &analyzerConfig, nil
+}
+
+// Connector looks up a connector configuration by name.
+// The returned error wraps ErrPluginNotFound if there is no such connector.
+func (pluginRegistry *PluginRegistry) Connector(ctx context.Context, connectorName string) (*ConnectorConfig, error) {
+	pluginRegistry.mutex.Lock()
+	defer pluginRegistry.mutex.Unlock()
+	if err := pluginRegistry.load(ctx); err != nil {
+		return nil, err
+	}
+	index, ok := pluginRegistry.connectorsByName[connectorName]
+	if !ok {
+		return nil, fmt.Errorf("%w: there is no connector named %s", ErrPluginNotFound, connectorName)
+	}
+	connectorConfig := pluginRegistry.connectors[index]
+	return &connectorConfig, nil
+}
+
+// Analyzers lists down the analyzer configurations matching the query, sorted by name. A nil query matches every analyzer.
+// e.g: the analyzers that can look up an IP without sharing it with anyone
+//
+//	registry.Analyzers(ctx, &gointelowl.AnalyzerQuery{ObservableType: "ip", LeaksInfo: gointelowl.Bool(false), ConfiguredOnly: true})
+func (pluginRegistry *PluginRegistry) Analyzers(ctx context.Context, analyzerQuery *AnalyzerQuery) ([]AnalyzerConfig, error) {
+	pluginRegistry.mutex.Lock()
+	defer pluginRegistry.mutex.Unlock()
+	if err := pluginRegistry.load(ctx); err != nil {
+		return nil, err
+	}
+	analyzerConfigs := []AnalyzerConfig{}
+	for index := range pluginRegistry.analyzers {
+		if analyzerQuery.matches(&pluginRegistry.analyzers[index]) {
+			analyzerConfigs = append(analyzerConfigs, pluginRegistry.analyzers[index])
+		}
+	}
+	return analyzerConfigs, nil
+}
+
+// Connectors lists down the connector configurations matching the query, sorted by name. A nil query matches every connector.
+func (pluginRegistry *PluginRegistry) Connectors(ctx context.Context, connectorQuery *ConnectorQuery) ([]ConnectorConfig, error) {
+	pluginRegistry.mutex.Lock()
+	defer pluginRegistry.mutex.Unlock()
+	if err := pluginRegistry.load(ctx); err != nil {
+		return nil, err
+	}
+	connectorConfigs := []ConnectorConfig{}
+	for index := range pluginRegistry.connectors {
+		if connectorQuery.matches(&pluginRegistry.connectors[index]) {
+			connectorConfigs = append(connectorConfigs, pluginRegistry.connectors[index])
+		}
+	}
+	return connectorConfigs, nil
+}
+
+// AnalyzerNames returns the names of the analyzers, a handy way to fill in BasicAnalysisParams.AnalyzersRequested.
+func AnalyzerNames(analyzerConfigs []AnalyzerConfig) []string {
+	analyzerNames := make([]string, 0, len(analyzerConfigs))
+	for _, analyzerConfig := range analyzerConfigs {
+		analyzerNames = append(analyzerNames, analyzerConfig.Name)
+	}
+	return analyzerNames
+}
+
+// ConnectorNames returns the names of the connectors, a handy way to fill in BasicAnalysisParams.ConnectorsRequested.
+func ConnectorNames(connectorConfigs []ConnectorConfig) []string {
+	connectorNames := make([]string, 0, len(connectorConfigs))
+	for _, connectorConfig := range connectorConfigs {
+		connectorNames = append(connectorNames, connectorConfig.Name)
+	}
+	return connectorNames
+}
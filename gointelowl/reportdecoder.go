@@ -0,0 +1,115 @@
+package gointelowl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrNoReportDecoder is returned when decoding the report of an analyzer that has no registered decoder.
+var ErrNoReportDecoder = errors.New("No report decoder")
+
+// ReportDecoder turns a report into a typed value e.g: a *VirusTotalReport.
+type ReportDecoder func(report *Report) (interface{}, error)
+
+// NewStructReportDecoder makes a ReportDecoder decoding the reports into the values made by newValue, which must return a pointer.
+//
+//	gointelowl.RegisterReportDecoder("My_Analyzer", gointelowl.NewStructReportDecoder(func() interface{} {
+//		return &MyAnalyzerReport{}
+//	}))
+func NewStructReportDecoder(newValue func() interface{}) ReportDecoder {
+	return func(report *Report) (interface{}, error) {
+		value := newValue()
+		if err := report.Decode(value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// ReportDecoderRegistry maps analyzer names to the ReportDecoder of their reports. It is safe for concurrent use.
+type ReportDecoderRegistry struct {
+	mutex    sync.RWMutex
+	decoders map[string]ReportDecoder
+}
+
+// NewReportDecoderRegistry makes a ReportDecoderRegistry knowing the reports of the popular analyzers:
+// VirusTotal, AbuseIPDB, Shodan, Yara, File_Info, Strings_Info, MaxMind and OTX.
+func NewReportDecoderRegistry() *ReportDecoderRegistry {
+	reportDecoderRegistry := &ReportDecoderRegistry{
+		decoders: map[string]ReportDecoder{},
+	}
+	for analyzerName, newValue := range builtinReportTypes {
+		reportDecoderRegistry.Register(analyzerName, NewStructReportDecoder(newValue))
+	}
+	return reportDecoderRegistry
+}
+
+// DefaultReportDecoderRegistry is the ReportDecoderRegistry used by Report.DecodeKnown.
+var DefaultReportDecoderRegistry = NewReportDecoderRegistry()
+
+// RegisterReportDecoder registers the decoder of an analyzer's reports in the DefaultReportDecoderRegistry.
+func RegisterReportDecoder(analyzerName string, decoder ReportDecoder) {
+	DefaultReportDecoderRegistry.Register(analyzerName, decoder)
+}
+
+// Register registers the decoder of an analyzer's reports, replacing the one registered before.
+func (reportDecoderRegistry *ReportDecoderRegistry) Register(analyzerName string, decoder ReportDecoder) {
+	reportDecoderRegistry.mutex.Lock()
+	defer reportDecoderRegistry.mutex.Unlock()
+	reportDecoderRegistry.decoders[analyzerName] = decoder
+}
+
+// Lookup returns the decoder of an analyzer's reports, if any.
+func (reportDecoderRegistry *ReportDecoderRegistry) Lookup(analyzerName string) (ReportDecoder, bool) {
+	reportDecoderRegistry.mutex.RLock()
+	defer reportDecoderRegistry.mutex.RUnlock()
+	decoder, ok := reportDecoderRegistry.decoders[analyzerName]
+	return decoder, ok
+}
+
+// AnalyzerNames lists down the analyzers that have a decoder, sorted alphabetically.
+func (reportDecoderRegistry *ReportDecoderRegistry) AnalyzerNames() []string {
+	reportDecoderRegistry.mutex.RLock()
+	defer reportDecoderRegistry.mutex.RUnlock()
+	analyzerNames := make([]string, 0, len(reportDecoderRegistry.decoders))
+	for analyzerName := range reportDecoderRegistry.decoders {
+		analyzerNames = append(analyzerNames, analyzerName)
+	}
+	sort.Strings(analyzerNames)
+	return analyzerNames
+}
+
+// Decode decodes a report with the decoder registered for its analyzer.
+// The returned error wraps ErrNoReportDecoder if there is none.
+func (reportDecoderRegistry *ReportDecoderRegistry) Decode(report *Report) (interface{}, error) {
+	decoder, ok := reportDecoderRegistry.Lookup(report.Name)
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", ErrNoReportDecoder, report.Name)
+	}
+	return decoder(report)
+}
+
+// Decode decodes the Report into the value pointed to by v, the way json.Unmarshal does.
+// e.g: decoding a VirusTotal report
+//
+//	virusTotalReport := gointelowl.VirusTotalReport{}
+//	err := report.Decode(&virusTotalReport)
+func (report *Report) Decode(v interface{}) error {
+	reportJson, err := json.Marshal(report.Report)
+	if err != nil {
+		return err
+	}
+	if unmarshalError := json.Unmarshal(reportJson, v); unmarshalError != nil {
+		return fmt.Errorf("Could not decode the report of %s: %w", report.Name, unmarshalError)
+	}
+	return nil
+}
+
+// DecodeKnown decodes the Report with the decoder registered for its analyzer in the DefaultReportDecoderRegistry
+// e.g: a *VirusTotalReport for VirusTotal_v3_Get_Observable. The returned error wraps ErrNoReportDecoder if there is none.
+func (report *Report) DecodeKnown() (interface{}, error) {
+	return DefaultReportDecoderRegistry.Decode(report)
+}
@@ -0,0 +1,257 @@
+package gointelowl
+
+import "sort"
+
+// builtinReportTypes maps the popular analyzers to the types of their reports.
+var builtinReportTypes = map[string]func() interface{}{
+	"VirusTotal_v3_Get_Observable":    func() interface{} { return &VirusTotalReport{} },
+	"VirusTotal_v3_Get_File":          func() interface{} { return &VirusTotalReport{} },
+	"VirusTotal_v3_Get_File_And_Scan": func() interface{} { return &VirusTotalReport{} },
+	"VirusTotal_v3_Scan_File":         func() interface{} { return &VirusTotalReport{} },
+	"AbuseIPDB":                       func() interface{} { return &AbuseIPDBReport{} },
+	"Shodan_Search":                   func() interface{} { return &ShodanReport{} },
+	"Shodan_Honeyscore":               func() interface{} { return &ShodanHoneyscoreReport{} },
+	"Yara":                            func() interface{} { return &YaraReport{} },
+	"File_Info":                       func() interface{} { return &FileInfoReport{} },
+	"Strings_Info":                    func() interface{} { return &StringsInfoReport{} },
+	"Strings_Info_Classic":            func() interface{} { return &StringsInfoReport{} },
+	"Strings_Info_ML":                 func() interface{} { return &StringsInfoReport{} },
+	"MaxMindGeoIP":                    func() interface{} { return &MaxMindReport{} },
+	"OTXQuery":                        func() interface{} { return &OTXReport{} },
+}
+
+// VirusTotalReport represents the report of the VirusTotal v3 analyzers, which is the VirusTotal API response.
+//
+// VirusTotal API docs: https://developers.virustotal.com/reference/objects
+type VirusTotalReport struct {
+	Data VirusTotalObject `json:"data"`
+	// Link is the VirusTotal GUI page of the observable or file
+	Link string `json:"link"`
+}
+
+// VirusTotalObject represents a file, URL, domain or IP address in VirusTotal.
+type VirusTotalObject struct {
+	ID            string                 `json:"id"`
+	Type          string                 `json:"type"`
+	Attributes    VirusTotalAttributes   `json:"attributes"`
+	Links         map[string]string      `json:"links"`
+	Relationships map[string]interface{} `json:"relationships"`
+}
+
+// VirusTotalAttributes represents the most used attributes of a VirusTotal object.
+type VirusTotalAttributes struct {
+	LastAnalysisDate    int64                             `json:"last_analysis_date"`
+	LastAnalysisStats   VirusTotalAnalysisStats           `json:"last_analysis_stats"`
+	LastAnalysisResults map[string]VirusTotalEngineResult `json:"last_analysis_results"`
+	Reputation          int                               `json:"reputation"`
+	TotalVotes          VirusTotalVotes                   `json:"total_votes"`
+	Tags                []string                          `json:"tags"`
+	// * files only
+	Md5             string `json:"md5"`
+	Sha1            string `json:"sha1"`
+	Sha256          string `json:"sha256"`
+	Size            int64  `json:"size"`
+	MeaningfulName  string `json:"meaningful_name"`
+	TypeDescription string `json:"type_description"`
+	// * domains and IP addresses only
+	Asn       int    `json:"asn"`
+	AsOwner   string `json:"as_owner"`
+	Country   string `json:"country"`
+	Registrar string `json:"registrar"`
+}
+
+// VirusTotalAnalysisStats represents how many engines gave each verdict.
+type VirusTotalAnalysisStats struct {
+	Harmless   int `json:"harmless"`
+	Malicious  int `json:"malicious"`
+	Suspicious int `json:"suspicious"`
+	Undetected int `json:"undetected"`
+	Timeout    int `json:"timeout"`
+}
+
+// VirusTotalEngineResult represents the verdict of an engine.
+type VirusTotalEngineResult struct {
+	Category   string `json:"category"`
+	EngineName string `json:"engine_name"`
+	Method     string `json:"method"`
+	Result     string `json:"result"`
+}
+
+// VirusTotalVotes represents the votes of the VirusTotal community.
+type VirusTotalVotes struct {
+	Harmless  int `json:"harmless"`
+	Malicious int `json:"malicious"`
+}
+
+// AbuseIPDBReport represents the report of the AbuseIPDB analyzer.
+//
+// AbuseIPDB API docs: https://docs.abuseipdb.com/#check-endpoint
+type AbuseIPDBReport struct {
+	Data AbuseIPDBData `json:"data"`
+	// Permalink is the AbuseIPDB page of the IP address
+	Permalink string `json:"permalink"`
+	// CategoriesFound maps the names of the reported categories to how many reports have them
+	CategoriesFound map[string]int `json:"categories_found"`
+}
+
+// AbuseIPDBData represents what AbuseIPDB knows about an IP address.
+type AbuseIPDBData struct {
+	IPAddress            string           `json:"ipAddress"`
+	IsPublic             bool             `json:"isPublic"`
+	IPVersion            int              `json:"ipVersion"`
+	IsWhitelisted        bool             `json:"isWhitelisted"`
+	AbuseConfidenceScore int              `json:"abuseConfidenceScore"`
+	CountryCode          string           `json:"countryCode"`
+	CountryName          string           `json:"countryName"`
+	UsageType            string           `json:"usageType"`
+	Isp                  string           `json:"isp"`
+	Domain               string           `json:"domain"`
+	Hostnames            []string         `json:"hostnames"`
+	TotalReports         int              `json:"totalReports"`
+	NumDistinctUsers     int              `json:"numDistinctUsers"`
+	LastReportedAt       string           `json:"lastReportedAt"`
+	Reports              []AbuseIPDBAbuse `json:"reports"`
+}
+
+// AbuseIPDBAbuse represents an abuse reported to AbuseIPDB.
+type AbuseIPDBAbuse struct {
+	ReportedAt          string `json:"reportedAt"`
+	Comment             string `json:"comment"`
+	Categories          []int  `json:"categories"`
+	ReporterID          int    `json:"reporterId"`
+	ReporterCountryCode string `json:"reporterCountryCode"`
+	ReporterCountryName string `json:"reporterCountryName"`
+}
+
+// ShodanReport represents the report of the Shodan_Search analyzer, which is the Shodan host information.
+//
+// Shodan API docs: https://developer.shodan.io/api
+type ShodanReport struct {
+	IPStr       string   `json:"ip_str"`
+	Ports       []int    `json:"ports"`
+	Hostnames   []string `json:"hostnames"`
+	Domains     []string `json:"domains"`
+	Org         string   `json:"org"`
+	Isp         string   `json:"isp"`
+	Asn         string   `json:"asn"`
+	Os          string   `json:"os"`
+	CountryCode string   `json:"country_code"`
+	CountryName string   `json:"country_name"`
+	City        string   `json:"city"`
+	LastUpdate  string   `json:"last_update"`
+	Tags        []string `json:"tags"`
+	Vulns       []string `json:"vulns"`
+}
+
+// ShodanHoneyscoreReport represents the report of the Shodan_Honeyscore analyzer.
+type ShodanHoneyscoreReport struct {
+	// Honeyscore goes from 0 to 1, the higher the more likely the IP address is a honeypot
+	Honeyscore float64 `json:"honeyscore"`
+}
+
+// YaraReport represents the report of the Yara analyzer: it maps each ruleset to the rules that matched.
+type YaraReport map[string][]YaraMatch
+
+// YaraMatch represents a rule that matched.
+type YaraMatch struct {
+	Match   string                 `json:"match"`
+	Strings interface{}            `json:"strings"`
+	Tags    []string               `json:"tags"`
+	Meta    map[string]interface{} `json:"meta"`
+	Path    string                 `json:"path"`
+	URL     string                 `json:"url"`
+	RuleURL string                 `json:"rule_url"`
+}
+
+// Matches lists down the matches of every ruleset, sorted by ruleset.
+func (yaraReport YaraReport) Matches() []YaraMatch {
+	rulesets := make([]string, 0, len(yaraReport))
+	for ruleset := range yaraReport {
+		rulesets = append(rulesets, ruleset)
+	}
+	sort.Strings(rulesets)
+	matches := []YaraMatch{}
+	for _, ruleset := range rulesets {
+		matches = append(matches, yaraReport[ruleset]...)
+	}
+	return matches
+}
+
+// FileInfoReport represents the report of the File_Info analyzer.
+type FileInfoReport struct {
+	Magic    string                 `json:"magic"`
+	Mimetype string                 `json:"mimetype"`
+	Filetype string                 `json:"filetype"`
+	Md5      string                 `json:"md5"`
+	Sha1     string                 `json:"sha1"`
+	Sha256   string                 `json:"sha256"`
+	Ssdeep   string                 `json:"ssdeep"`
+	Tlsh     string                 `json:"tlsh"`
+	Exiftool map[string]interface{} `json:"exiftool"`
+}
+
+// StringsInfoReport represents the report of the Strings_Info analyzers.
+type StringsInfoReport struct {
+	Data []string `json:"data"`
+	// ExceededMaxNumberOfStrings is set when Data has been cut to the max_number_of_strings parameter
+	ExceededMaxNumberOfStrings bool     `json:"exceeded_max_number_of_strings"`
+	Uris                       []string `json:"uris"`
+}
+
+// MaxMindReport represents the report of the MaxMindGeoIP analyzer.
+//
+// MaxMind docs: https://dev.maxmind.com/geoip/docs/databases
+type MaxMindReport struct {
+	City                         MaxMindPlace    `json:"city"`
+	Continent                    MaxMindPlace    `json:"continent"`
+	Country                      MaxMindPlace    `json:"country"`
+	RegisteredCountry            MaxMindPlace    `json:"registered_country"`
+	Location                     MaxMindLocation `json:"location"`
+	AutonomousSystemNumber       int             `json:"autonomous_system_number"`
+	AutonomousSystemOrganization string          `json:"autonomous_system_organization"`
+}
+
+// MaxMindPlace represents a city, a continent or a country.
+type MaxMindPlace struct {
+	GeonameID int               `json:"geoname_id"`
+	IsoCode   string            `json:"iso_code"`
+	Code      string            `json:"code"`
+	Names     map[string]string `json:"names"`
+}
+
+// Name returns the English name of the place.
+func (maxMindPlace *MaxMindPlace) Name() string {
+	return maxMindPlace.Names["en"]
+}
+
+// MaxMindLocation represents where an IP address is.
+type MaxMindLocation struct {
+	AccuracyRadius int     `json:"accuracy_radius"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	TimeZone       string  `json:"time_zone"`
+}
+
+// OTXReport represents the report of the OTXQuery analyzer.
+//
+// AlienVault OTX API docs: https://otx.alienvault.com/api
+type OTXReport struct {
+	Pulses         []OTXPulse               `json:"pulses"`
+	Geo            map[string]interface{}   `json:"geo"`
+	MalwareSamples []string                 `json:"malware_samples"`
+	PassiveDNS     []map[string]interface{} `json:"passive_dns"`
+	URLList        []map[string]interface{} `json:"url_list"`
+	Analysis       map[string]interface{}   `json:"analysis"`
+}
+
+// OTXPulse represents an OTX pulse, a set of indicators shared by the community.
+type OTXPulse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	References  []string `json:"references"`
+	Created     string   `json:"created"`
+	Modified    string   `json:"modified"`
+	Tlp         string   `json:"tlp"`
+}
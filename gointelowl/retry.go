@@ -0,0 +1,192 @@
+package gointelowl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy represents how the IntelOwlClient retries requests that failed because of transient errors.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int `json:"max_attempts"`
+	// BaseDelay is the delay before the first retry, it doubles on every following retry.
+	// In JSON it is in seconds e.g: 0.5
+	BaseDelay time.Duration `json:"-"`
+	// MaxDelay caps the delay between two attempts (0 means no cap), in seconds in JSON
+	MaxDelay time.Duration `json:"-"`
+	// Jitter is the fraction (0 to 1) of every delay that gets randomized
+	Jitter float64 `json:"jitter"`
+	// RetryableStatusCodes are the response status codes that are retried
+	RetryableStatusCodes []int `json:"retryable_status_codes"`
+	// RetryableError decides which network errors are retried, nil means IsTransientNetworkError
+	RetryableError func(err error) bool `json:"-"`
+	// RetryNonIdempotent allows retrying POST and PATCH requests
+	RetryNonIdempotent bool `json:"retry_non_idempotent"`
+}
+
+// retryPolicyAlias has the fields of RetryPolicy without its JSON methods.
+type retryPolicyAlias RetryPolicy
+
+// retryPolicyJSON is the JSON form of a RetryPolicy, with its delays in seconds like the Timeout of the client.
+type retryPolicyJSON struct {
+	*retryPolicyAlias
+	BaseDelay float64 `json:"base_delay"`
+	MaxDelay  float64 `json:"max_delay"`
+}
+
+// MarshalJSON writes the delays of the RetryPolicy in seconds.
+func (retryPolicy RetryPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(retryPolicyJSON{
+		retryPolicyAlias: (*retryPolicyAlias)(&retryPolicy),
+		BaseDelay:        retryPolicy.BaseDelay.Seconds(),
+		MaxDelay:         retryPolicy.MaxDelay.Seconds(),
+	})
+}
+
+// UnmarshalJSON reads the delays of the RetryPolicy in seconds.
+func (retryPolicy *RetryPolicy) UnmarshalJSON(data []byte) error {
+	retryPolicyJson := retryPolicyJSON{
+		retryPolicyAlias: (*retryPolicyAlias)(retryPolicy),
+		BaseDelay:        retryPolicy.BaseDelay.Seconds(),
+		MaxDelay:         retryPolicy.MaxDelay.Seconds(),
+	}
+	if err := json.Unmarshal(data, &retryPolicyJson); err != nil {
+		return err
+	}
+	retryPolicy.BaseDelay = secondsToDuration(retryPolicyJson.BaseDelay)
+	retryPolicy.MaxDelay = secondsToDuration(retryPolicyJson.MaxDelay)
+	return nil
+}
+
+// secondsToDuration converts a number of seconds, fractional or not, to a time.Duration.
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// DefaultRetryPolicy returns a RetryPolicy that retries idempotent requests up to 4 times
+// on 429, 502, 503, 504 and transient network errors.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// IsTransientNetworkError reports whether the error is a network error worth retrying:
+// connection resets and refusals, broken pipes, unexpected EOFs and timeouts.
+func IsTransientNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	return false
+}
+
+// isIdempotentMethod reports whether sending the same request twice has the same effect as sending it once.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shouldRetry decides whether the request that failed with the given error on the given attempt is retried.
+func (retryPolicy *RetryPolicy) shouldRetry(ctx context.Context, request *http.Request, attempt int, err error) bool {
+	if retryPolicy == nil || attempt >= retryPolicy.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if !retryPolicy.RetryNonIdempotent && !isIdempotentMethod(request.Method) {
+		return false
+	}
+	// * a body that cannot be rebuilt cannot be sent again
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return false
+	}
+	var intelOwlError *IntelOwlError
+	if errors.As(err, &intelOwlError) {
+		for _, statusCode := range retryPolicy.RetryableStatusCodes {
+			if statusCode == intelOwlError.StatusCode {
+				return true
+			}
+		}
+		return false
+	}
+	if retryPolicy.RetryableError != nil {
+		return retryPolicy.RetryableError(err)
+	}
+	return IsTransientNetworkError(err)
+}
+
+// delay calculates how long to wait before the next attempt, honoring the Retry-After header if the server sent one.
+// The Retry-After delay is capped by MaxDelay too.
+func (retryPolicy *RetryPolicy) delay(attempt int, err error) time.Duration {
+	var intelOwlError *IntelOwlError
+	if errors.As(err, &intelOwlError) && intelOwlError.Response != nil {
+		if retryAfter, ok := parseRetryAfter(intelOwlError.Response.Header.Get("Retry-After")); ok {
+			if retryPolicy.MaxDelay > 0 && retryAfter > retryPolicy.MaxDelay {
+				return retryPolicy.MaxDelay
+			}
+			return retryAfter
+		}
+	}
+	return backoffDelay(attempt, retryPolicy.BaseDelay, retryPolicy.MaxDelay, 2, retryPolicy.Jitter)
+}
+
+// parseRetryAfter parses the Retry-After header which is either a number of seconds or an HTTP date.
+func parseRetryAfter(retryAfter string) (time.Duration, bool) {
+	if retryAfter == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// rewindRequest returns a copy of the request with a fresh body, ready to be sent again.
+func rewindRequest(ctx context.Context, request *http.Request) (*http.Request, error) {
+	retryRequest := request.Clone(ctx)
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retryRequest.Body = body
+	}
+	return retryRequest, nil
+}
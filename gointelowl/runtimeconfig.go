@@ -0,0 +1,205 @@
+package gointelowl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RuntimeConfigurationError is returned when a runtime configuration does not match the analyzers' parameters.
+type RuntimeConfigurationError struct {
+	Problems []string
+}
+
+// Error lets you implement the error interface.
+func (runtimeConfigurationError *RuntimeConfigurationError) Error() string {
+	return fmt.Sprintf("Invalid runtime configuration: %s", strings.Join(runtimeConfigurationError.Problems, "; "))
+}
+
+// RuntimeConfigurationBuilder builds the RuntimeConfiguration of an analysis, checking every parameter name and
+// value against the analyzers' Params so that mistakes are caught before IntelOwl rejects the analysis.
+//
+//	runtimeConfiguration, err := builder.
+//		Set("Strings_Info", "max_number_of_strings", 1000).
+//		Set("Strings_Info", "rank_strings", true).
+//		Build()
+type RuntimeConfigurationBuilder struct {
+	analyzers     map[string]AnalyzerConfig
+	configuration map[string]map[string]interface{}
+}
+
+// NewRuntimeConfigurationBuilder makes a RuntimeConfigurationBuilder out of the analyzer configurations.
+func NewRuntimeConfigurationBuilder(analyzerConfigs []AnalyzerConfig) *RuntimeConfigurationBuilder {
+	builder := &RuntimeConfigurationBuilder{
+		analyzers:     map[string]AnalyzerConfig{},
+		configuration: map[string]map[string]interface{}{},
+	}
+	for _, analyzerConfig := range analyzerConfigs {
+		builder.analyzers[analyzerConfig.Name] = analyzerConfig
+	}
+	return builder
+}
+
+// NewRuntimeConfigurationBuilder fetches the analyzer configurations of your IntelOwl instance to make a RuntimeConfigurationBuilder.
+//
+//	Endpoint: GET /api/get_analyzer_configs
+func (client *IntelOwlClient) NewRuntimeConfigurationBuilder(ctx context.Context) (*RuntimeConfigurationBuilder, error) {
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewRuntimeConfigurationBuilder(*analyzerConfigs), nil
+}
+
+// Set sets a parameter of an analyzer, overriding any value set before.
+// Mistakes are reported by Build.
+func (builder *RuntimeConfigurationBuilder) Set(analyzerName string, parameterName string, value interface{}) *RuntimeConfigurationBuilder {
+	if builder.configuration[analyzerName] == nil {
+		builder.configuration[analyzerName] = map[string]interface{}{}
+	}
+	builder.configuration[analyzerName][parameterName] = value
+	return builder
+}
+
+// Build checks every parameter set and returns the runtime configuration, ready for BasicAnalysisParams.RuntimeConfiguration.
+// The returned error is a *RuntimeConfigurationError listing every mistake.
+func (builder *RuntimeConfigurationBuilder) Build() (map[string]interface{}, error) {
+	runtimeConfiguration := make(map[string]interface{}, len(builder.configuration))
+	for analyzerName, parameters := range builder.configuration {
+		analyzerParameters := make(map[string]interface{}, len(parameters))
+		for parameterName, value := range parameters {
+			analyzerParameters[parameterName] = value
+		}
+		runtimeConfiguration[analyzerName] = analyzerParameters
+	}
+	if err := builder.Validate(runtimeConfiguration); err != nil {
+		return nil, err
+	}
+	return runtimeConfiguration, nil
+}
+
+// Apply builds the runtime configuration and sets it in the params.
+// Configuring an analyzer that is not requested is a mistake too, unless every analyzer is requested.
+func (builder *RuntimeConfigurationBuilder) Apply(params *BasicAnalysisParams) error {
+	runtimeConfiguration, err := builder.Build()
+	problems := []string{}
+	if runtimeConfigurationError, ok := err.(*RuntimeConfigurationError); ok {
+		problems = runtimeConfigurationError.Problems
+	} else if err != nil {
+		return err
+	}
+	if len(params.AnalyzersRequested) > 0 {
+		analyzerNames := make([]string, 0, len(builder.configuration))
+		for analyzerName := range builder.configuration {
+			analyzerNames = append(analyzerNames, analyzerName)
+		}
+		sort.Strings(analyzerNames)
+		for _, analyzerName := range analyzerNames {
+			if _, known := builder.analyzers[analyzerName]; known && !containsString(params.AnalyzersRequested, analyzerName) {
+				problems = append(problems, fmt.Sprintf("%s is configured but not requested", analyzerName))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &RuntimeConfigurationError{Problems: problems}
+	}
+	params.RuntimeConfiguration = runtimeConfiguration
+	return nil
+}
+
+// Validate checks a runtime configuration made by hand e.g: decoded from a file.
+// The returned error is a *RuntimeConfigurationError listing every mistake.
+func (builder *RuntimeConfigurationBuilder) Validate(runtimeConfiguration map[string]interface{}) error {
+	problems := []string{}
+	for _, analyzerName := range sortedKeys(runtimeConfiguration) {
+		analyzerConfig, ok := builder.analyzers[analyzerName]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("there is no analyzer named %s", analyzerName))
+			continue
+		}
+		parameters, ok := runtimeConfiguration[analyzerName].(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("the parameters of %s must be a map[string]interface{}, got %T", analyzerName, runtimeConfiguration[analyzerName]))
+			continue
+		}
+		for _, parameterName := range sortedKeys(parameters) {
+			parameter, ok := analyzerConfig.Params[parameterName]
+			if !ok {
+				problem := fmt.Sprintf("%s has no parameter named %s", analyzerName, parameterName)
+				if suggestion := closestName(parameterName, analyzerConfig.Params); suggestion != "" {
+					problem += fmt.Sprintf(" (did you mean %s?)", suggestion)
+				}
+				problems = append(problems, problem)
+				continue
+			}
+			if !parameter.Accepts(parameters[parameterName]) {
+				problems = append(problems, fmt.Sprintf("the parameter %s of %s is of type %s, got %T", parameterName, analyzerName, parameter.TypeName(), parameters[parameterName]))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &RuntimeConfigurationError{Problems: problems}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map sorted, so that the problems are always reported in the same order.
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// closestName finds the parameter name that is most likely meant by a misspelled one, an empty string if none is close enough.
+func closestName(name string, parameters map[string]Parameter) string {
+	closest := ""
+	closestDistance := 3
+	for _, parameterName := range sortedParameterNames(parameters) {
+		if distance := editDistance(strings.ToLower(name), strings.ToLower(parameterName)); distance < closestDistance {
+			closest = parameterName
+			closestDistance = distance
+		}
+	}
+	return closest
+}
+
+// sortedParameterNames returns the names of the parameters sorted.
+func sortedParameterNames(parameters map[string]Parameter) []string {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// editDistance is the Levenshtein distance between two strings.
+func editDistance(first string, second string) int {
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(second)]
+}
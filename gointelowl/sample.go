@@ -0,0 +1,165 @@
+package gointelowl
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/intelowlproject/go-intelowl/constants"
+)
+
+// DefaultZipPassword is the password conventionally used to share malware samples.
+const DefaultZipPassword = "infected"
+
+var (
+	// ErrSampleTooLarge is returned when a sample is bigger than SampleDownloadOptions.MaxSize.
+	ErrSampleTooLarge = errors.New("The sample is larger than the maximum size")
+	// ErrSampleMd5Mismatch is returned when the MD5 of a downloaded sample does not match the job's md5.
+	ErrSampleMd5Mismatch = errors.New("The MD5 of the sample does not match the job's md5")
+	// ErrSampleNoMd5 is returned when the MD5 of a sample has to be verified but the job has no md5.
+	ErrSampleNoMd5 = errors.New("The job has no md5 to verify the sample against")
+)
+
+// SampleDownloadOptions represents the options of a streamed sample download.
+type SampleDownloadOptions struct {
+	// MaxSize is the maximum size of the sample in bytes (0 means no limit)
+	MaxSize int64
+	// VerifyMd5 fetches the job to check the MD5 of the sample against the job's md5
+	VerifyMd5 bool
+	// Zip saves the sample in a password protected zip archive
+	Zip bool
+	// ZipPassword is the password of the zip archive, defaults to DefaultZipPassword
+	ZipPassword string
+	// ZipEntryName is the name of the sample inside the zip archive.
+	// It defaults to the job's file name when the job is fetched, "job_{jobID}_sample" otherwise.
+	ZipEntryName string
+}
+
+// SampleDownloadResult represents the sample that has been downloaded.
+type SampleDownloadResult struct {
+	// Size is the size of the sample in bytes, not of the zip archive
+	Size   int64
+	Md5    string
+	Sha256 string
+}
+
+// DownloadSampleTo streams the File sample of the given job into the writer,
+// computing its MD5 and SHA256 on the way.
+// The download stops with ErrSampleTooLarge as soon as the sample exceeds MaxSize: what was written so far is left to the caller.
+// If VerifyMd5 is set, ErrSampleMd5Mismatch is returned along with the result when the hashes differ
+// and ErrSampleNoMd5, before downloading anything, when the job has no md5 e.g: it did not analyze a file.
+// Mind that the IntelOwlClientOptions.Timeout also applies to reading the sample.
+//
+//	Endpoint: GET /api/jobs/{jobID}/download_sample
+//
+// IntelOwl REST API docs: https://intelowlproject.github.io/docs/IntelOwl/api_docs/#tag/jobs/operation/jobs_download_sample_retrieve
+func (jobService *JobService) DownloadSampleTo(ctx context.Context, jobId uint64, writer io.Writer, options *SampleDownloadOptions) (*SampleDownloadResult, error) {
+	if options == nil {
+		options = &SampleDownloadOptions{}
+	}
+	var job *Job
+	if options.VerifyMd5 {
+		var err error
+		job, err = jobService.Get(ctx, jobId)
+		if err != nil {
+			return nil, err
+		}
+		if job.Md5 == "" {
+			return nil, fmt.Errorf("%w: job #%d", ErrSampleNoMd5, jobId)
+		}
+	}
+
+	route := jobService.client.options.Url + constants.DOWNLOAD_SAMPLE_JOB_URL
+	requestUrl := fmt.Sprintf(route, jobId)
+	contentType := "application/json"
+	method := "GET"
+	request, err := jobService.client.buildRequest(ctx, "JobService.DownloadSampleTo", method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := jobService.client.newStreamRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer successResp.Body.Close()
+
+	// * Wrapping the writer in the zip archive
+	destination := writer
+	var zipWriter *encryptedZipWriter
+	if options.Zip {
+		zipPassword := options.ZipPassword
+		if zipPassword == "" {
+			zipPassword = DefaultZipPassword
+		}
+		zipEntryName := options.ZipEntryName
+		if zipEntryName == "" && job != nil && job.FileName != "" {
+			zipEntryName = job.FileName
+		}
+		if zipEntryName == "" {
+			zipEntryName = fmt.Sprintf("job_%d_sample", jobId)
+		}
+		zipWriter, err = newEncryptedZipWriter(writer, zipEntryName, zipPassword, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		destination = zipWriter
+	}
+
+	// * Streaming the sample
+	md5Hash := md5.New()
+	sha256Hash := sha256.New()
+	body := io.Reader(successResp.Body)
+	if options.MaxSize > 0 {
+		body = io.LimitReader(successResp.Body, options.MaxSize)
+	}
+	size, err := io.Copy(io.MultiWriter(destination, md5Hash, sha256Hash), body)
+	if err != nil {
+		return nil, err
+	}
+	if options.MaxSize > 0 {
+		// * one more byte means the sample did not fit
+		if extraBytes, _ := io.ReadFull(successResp.Body, make([]byte, 1)); extraBytes > 0 {
+			return nil, fmt.Errorf("%w: %d bytes", ErrSampleTooLarge, options.MaxSize)
+		}
+	}
+	if zipWriter != nil {
+		if err := zipWriter.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &SampleDownloadResult{
+		Size:   size,
+		Md5:    hex.EncodeToString(md5Hash.Sum(nil)),
+		Sha256: hex.EncodeToString(sha256Hash.Sum(nil)),
+	}
+	if job != nil && !strings.EqualFold(job.Md5, result.Md5) {
+		return result, fmt.Errorf("%w: expected %s, got %s", ErrSampleMd5Mismatch, job.Md5, result.Md5)
+	}
+	return result, nil
+}
+
+// DownloadSampleToFile streams the File sample of the given job into the file at the given path, see DownloadSampleTo.
+// The file is removed if the download fails or the sample does not pass the checks.
+func (jobService *JobService) DownloadSampleToFile(ctx context.Context, jobId uint64, filePath string, options *SampleDownloadOptions) (*SampleDownloadResult, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create %s: %w", filePath, err)
+	}
+	result, err := jobService.DownloadSampleTo(ctx, jobId, file, options)
+	if closeError := file.Close(); err == nil && closeError != nil {
+		err = closeError
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,163 @@
+package gointelowl
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// JobStatus represents the status of an IntelOwl job.
+// Statuses this version does not know about (e.g: added by a newer IntelOwl) are kept as they are and are not terminal.
+type JobStatus string
+
+// Values of the JobStatus enum.
+const (
+	JobStatusPending              JobStatus = "pending"
+	JobStatusRunning              JobStatus = "running"
+	JobStatusReportedWithoutFails JobStatus = "reported_without_fails"
+	JobStatusReportedWithFails    JobStatus = "reported_with_fails"
+	JobStatusKilled               JobStatus = "killed"
+	JobStatusFailed               JobStatus = "failed"
+)
+
+// jobStatuses are the known job statuses.
+var jobStatuses = []JobStatus{
+	JobStatusPending,
+	JobStatusRunning,
+	JobStatusReportedWithoutFails,
+	JobStatusReportedWithFails,
+	JobStatusKilled,
+	JobStatusFailed,
+}
+
+// String returns the status as IntelOwl sends it.
+func (jobStatus JobStatus) String() string {
+	return string(jobStatus)
+}
+
+// IsKnown reports whether the status is one of the values of the JobStatus enum.
+func (jobStatus JobStatus) IsKnown() bool {
+	for _, knownStatus := range jobStatuses {
+		if jobStatus == knownStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether a job with the status has stopped running: it has been reported, has failed or has been killed.
+func (jobStatus JobStatus) IsTerminal() bool {
+	switch jobStatus {
+	case JobStatusReportedWithoutFails, JobStatusReportedWithFails, JobStatusKilled, JobStatusFailed:
+		return true
+	}
+	return false
+}
+
+// IsReported reports whether a job with the status has been reported, even if some of its plugins failed.
+func (jobStatus JobStatus) IsReported() bool {
+	return jobStatus == JobStatusReportedWithoutFails || jobStatus == JobStatusReportedWithFails
+}
+
+// IsSuccessful reports whether a job with the status has been reported with every plugin succeeding.
+func (jobStatus JobStatus) IsSuccessful() bool {
+	return jobStatus == JobStatusReportedWithoutFails
+}
+
+// Implementing the MarshalJSON interface to make our custom Marshal for the enum
+func (jobStatus JobStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(jobStatus))
+}
+
+// Implementing the UnmarshalJSON interface to make our custom Unmarshal for the enum.
+// Known statuses are recognized whatever their case, unknown ones are kept as they are and null leaves the status as it is.
+func (jobStatus *JobStatus) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var statusString string
+	if err := json.Unmarshal(data, &statusString); err != nil {
+		return err
+	}
+	*jobStatus = JobStatus(statusString)
+	for _, knownStatus := range jobStatuses {
+		if strings.EqualFold(statusString, string(knownStatus)) {
+			*jobStatus = knownStatus
+		}
+	}
+	return nil
+}
+
+// ReportStatus represents the status of an analyzer or connector report.
+// Statuses this version does not know about are kept as they are and are not terminal.
+type ReportStatus string
+
+// Values of the ReportStatus enum.
+const (
+	ReportStatusPending ReportStatus = "PENDING"
+	ReportStatusRunning ReportStatus = "RUNNING"
+	ReportStatusSuccess ReportStatus = "SUCCESS"
+	ReportStatusFailed  ReportStatus = "FAILED"
+	ReportStatusKilled  ReportStatus = "KILLED"
+)
+
+// reportStatuses are the known report statuses.
+var reportStatuses = []ReportStatus{
+	ReportStatusPending,
+	ReportStatusRunning,
+	ReportStatusSuccess,
+	ReportStatusFailed,
+	ReportStatusKilled,
+}
+
+// String returns the status as IntelOwl sends it.
+func (reportStatus ReportStatus) String() string {
+	return string(reportStatus)
+}
+
+// IsKnown reports whether the status is one of the values of the ReportStatus enum.
+func (reportStatus ReportStatus) IsKnown() bool {
+	for _, knownStatus := range reportStatuses {
+		if reportStatus == knownStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether a plugin with the status has stopped running.
+func (reportStatus ReportStatus) IsTerminal() bool {
+	switch reportStatus {
+	case ReportStatusSuccess, ReportStatusFailed, ReportStatusKilled:
+		return true
+	}
+	return false
+}
+
+// IsSuccessful reports whether a plugin with the status has succeeded.
+func (reportStatus ReportStatus) IsSuccessful() bool {
+	return reportStatus == ReportStatusSuccess
+}
+
+// Implementing the MarshalJSON interface to make our custom Marshal for the enum
+func (reportStatus ReportStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(reportStatus))
+}
+
+// Implementing the UnmarshalJSON interface to make our custom Unmarshal for the enum.
+// Known statuses are recognized whatever their case, unknown ones are kept as they are and null leaves the status as it is.
+func (reportStatus *ReportStatus) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var statusString string
+	if err := json.Unmarshal(data, &statusString); err != nil {
+		return err
+	}
+	*reportStatus = ReportStatus(statusString)
+	for _, knownStatus := range reportStatuses {
+		if strings.EqualFold(statusString, string(knownStatus)) {
+			*reportStatus = knownStatus
+		}
+	}
+	return nil
+}
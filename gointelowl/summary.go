@@ -0,0 +1,227 @@
+package gointelowl
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNotScored is returned by a ScoringRule when the report says nothing about the observable or file
+// e.g: VirusTotal has never seen it.
+var ErrNotScored = errors.New("Not scored")
+
+// Verdict represents how dangerous an observable or file is deemed to be.
+type Verdict string
+
+// Values of the Verdict enum.
+const (
+	VerdictBenign     Verdict = "benign"
+	VerdictSuspicious Verdict = "suspicious"
+	VerdictMalicious  Verdict = "malicious"
+	// VerdictUnknown means no analyzer could tell
+	VerdictUnknown Verdict = "unknown"
+)
+
+// ScoringRule turns the report of an analyzer into a score going from 0 (benign) to 1 (malicious).
+// It returns ErrNotScored, or any other error, when the report cannot be scored.
+type ScoringRule func(report *Report) (float64, error)
+
+// AnalyzerVerdict represents what an analyzer thinks of the observable or file.
+type AnalyzerVerdict struct {
+	Analyzer string  `json:"analyzer"`
+	Score    float64 `json:"score"`
+	Verdict  Verdict `json:"verdict"`
+	// Reason explains an unknown verdict e.g: no ScoringRule for the analyzer
+	Reason string `json:"reason,omitempty"`
+}
+
+// FailedAnalyzer represents an analyzer that did not succeed.
+type FailedAnalyzer struct {
+	Analyzer string       `json:"analyzer"`
+	Status   ReportStatus `json:"status"`
+	Errors   []string     `json:"errors"`
+}
+
+// JobSummary represents the verdict of a job, aggregated over its analyzer reports.
+type JobSummary struct {
+	JobID int `json:"job_id"`
+	// Score is the highest score of the analyzers: one analyzer sure that it is malicious is enough
+	Score    float64           `json:"score"`
+	Verdict  Verdict           `json:"verdict"`
+	Verdicts []AnalyzerVerdict `json:"verdicts"`
+	Failed   []FailedAnalyzer  `json:"failed"`
+	// TotalProcessTime and MaxProcessTime are computed over the analyzer reports, in seconds
+	TotalProcessTime float64 `json:"total_process_time"`
+	MaxProcessTime   float64 `json:"max_process_time"`
+	SlowestAnalyzer  string  `json:"slowest_analyzer"`
+}
+
+// Summarizer computes the verdict of jobs with a ScoringRule per analyzer. It is safe for concurrent use.
+type Summarizer struct {
+	// SuspiciousThreshold and MaliciousThreshold are the scores from which a verdict is suspicious or malicious
+	SuspiciousThreshold float64
+	MaliciousThreshold  float64
+	mutex               sync.RWMutex
+	rules               map[string]ScoringRule
+}
+
+// NewSummarizer makes a Summarizer with the default thresholds (0.3 and 0.7)
+// and the scoring rules of the VirusTotal, AbuseIPDB and OTX analyzers.
+func NewSummarizer() *Summarizer {
+	summarizer := &Summarizer{
+		SuspiciousThreshold: 0.3,
+		MaliciousThreshold:  0.7,
+		rules:               map[string]ScoringRule{},
+	}
+	for _, analyzerName := range []string{"VirusTotal_v3_Get_Observable", "VirusTotal_v3_Get_File", "VirusTotal_v3_Get_File_And_Scan", "VirusTotal_v3_Scan_File"} {
+		summarizer.Register(analyzerName, VirusTotalScoringRule)
+	}
+	summarizer.Register("AbuseIPDB", AbuseIPDBScoringRule)
+	summarizer.Register("OTXQuery", OTXScoringRule)
+	return summarizer
+}
+
+// DefaultSummarizer is the Summarizer used by Job.Summarize.
+var DefaultSummarizer = NewSummarizer()
+
+// RegisterScoringRule registers the scoring rule of an analyzer in the DefaultSummarizer.
+func RegisterScoringRule(analyzerName string, rule ScoringRule) {
+	DefaultSummarizer.Register(analyzerName, rule)
+}
+
+// Register registers the scoring rule of an analyzer, replacing the one registered before.
+func (summarizer *Summarizer) Register(analyzerName string, rule ScoringRule) {
+	summarizer.mutex.Lock()
+	defer summarizer.mutex.Unlock()
+	summarizer.rules[analyzerName] = rule
+}
+
+// verdict labels a score.
+func (summarizer *Summarizer) verdict(score float64) Verdict {
+	switch {
+	case score >= summarizer.MaliciousThreshold:
+		return VerdictMalicious
+	case score >= summarizer.SuspiciousThreshold:
+		return VerdictSuspicious
+	}
+	return VerdictBenign
+}
+
+// Summarize computes the verdict of every successful analyzer and of the whole job.
+// The analyzers that failed or were killed are listed in Failed, the ones still running are left out.
+func (summarizer *Summarizer) Summarize(job *Job) *JobSummary {
+	summarizer.mutex.RLock()
+	defer summarizer.mutex.RUnlock()
+	jobSummary := &JobSummary{
+		JobID:    job.ID,
+		Verdict:  VerdictUnknown,
+		Verdicts: []AnalyzerVerdict{},
+		Failed:   []FailedAnalyzer{},
+	}
+	scored := false
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		jobSummary.TotalProcessTime += report.ProcessTime
+		if report.ProcessTime > jobSummary.MaxProcessTime {
+			jobSummary.MaxProcessTime = report.ProcessTime
+			jobSummary.SlowestAnalyzer = report.Name
+		}
+		if !report.Status.IsTerminal() {
+			continue
+		}
+		if !report.Status.IsSuccessful() {
+			jobSummary.Failed = append(jobSummary.Failed, FailedAnalyzer{
+				Analyzer: report.Name,
+				Status:   report.Status,
+				Errors:   report.Errors,
+			})
+			continue
+		}
+		analyzerVerdict := AnalyzerVerdict{
+			Analyzer: report.Name,
+			Verdict:  VerdictUnknown,
+		}
+		rule, ok := summarizer.rules[report.Name]
+		if !ok {
+			analyzerVerdict.Reason = "no scoring rule"
+			jobSummary.Verdicts = append(jobSummary.Verdicts, analyzerVerdict)
+			continue
+		}
+		score, err := rule(report)
+		if err != nil {
+			analyzerVerdict.Reason = err.Error()
+			jobSummary.Verdicts = append(jobSummary.Verdicts, analyzerVerdict)
+			continue
+		}
+		score = clampScore(score)
+		analyzerVerdict.Score = score
+		analyzerVerdict.Verdict = summarizer.verdict(score)
+		jobSummary.Verdicts = append(jobSummary.Verdicts, analyzerVerdict)
+		if !scored || score > jobSummary.Score {
+			jobSummary.Score = score
+		}
+		scored = true
+	}
+	if scored {
+		jobSummary.Verdict = summarizer.verdict(jobSummary.Score)
+	}
+	return jobSummary
+}
+
+// Summarize computes the verdict of the job with the DefaultSummarizer.
+func (job *Job) Summarize() *JobSummary {
+	return DefaultSummarizer.Summarize(job)
+}
+
+// clampScore keeps a score between 0 and 1.
+func clampScore(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+// VirusTotalScoringRule scores a VirusTotal report by its detection ratio: a quarter of the engines
+// flagging the observable or file as malicious (suspicious counting half) is a score of 1.
+func VirusTotalScoringRule(report *Report) (float64, error) {
+	virusTotalReport := VirusTotalReport{}
+	if err := report.Decode(&virusTotalReport); err != nil {
+		return 0, err
+	}
+	stats := virusTotalReport.Data.Attributes.LastAnalysisStats
+	engines := stats.Harmless + stats.Malicious + stats.Suspicious + stats.Undetected
+	if engines == 0 {
+		return 0, fmt.Errorf("%w: no engine analyzed it", ErrNotScored)
+	}
+	detectionRatio := (float64(stats.Malicious) + float64(stats.Suspicious)/2) / float64(engines)
+	return clampScore(detectionRatio * 4), nil
+}
+
+// AbuseIPDBScoringRule scores an AbuseIPDB report by its abuse confidence score, whitelisted IP addresses scoring 0.
+func AbuseIPDBScoringRule(report *Report) (float64, error) {
+	abuseIPDBReport := AbuseIPDBReport{}
+	if err := report.Decode(&abuseIPDBReport); err != nil {
+		return 0, err
+	}
+	if abuseIPDBReport.Data.IsWhitelisted {
+		return 0, nil
+	}
+	return float64(abuseIPDBReport.Data.AbuseConfidenceScore) / 100, nil
+}
+
+// OTXScoringRule scores an OTX report by the number of pulses mentioning the observable:
+// each pulse adds 0.1, up to a suspicious 0.5 as pulses are shared by anyone.
+func OTXScoringRule(report *Report) (float64, error) {
+	otxReport := OTXReport{}
+	if err := report.Decode(&otxReport); err != nil {
+		return 0, err
+	}
+	score := float64(len(otxReport.Pulses)) / 10
+	if score > 0.5 {
+		score = 0.5
+	}
+	return score, nil
+}
@@ -48,7 +48,7 @@ func (tagService *TagService) List(ctx context.Context) (*[]Tag, error) {
 	requestUrl := tagService.client.options.Url + constants.BASE_TAG_URL
 	contentType := "application/json"
 	method := "GET"
-	request, err := tagService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := tagService.client.buildRequest(ctx, "TagService.List", method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +78,7 @@ func (tagService *TagService) Get(ctx context.Context, tagId uint64) (*Tag, erro
 	requestUrl := fmt.Sprintf(route, tagId)
 	contentType := "application/json"
 	method := "GET"
-	request, err := tagService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := tagService.client.buildRequest(ctx, "TagService.Get", method, contentType, nil, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +108,7 @@ func (tagService *TagService) Create(ctx context.Context, tagParams *TagParams)
 	contentType := "application/json"
 	method := "POST"
 	body := bytes.NewBuffer(tagJson)
-	request, err := tagService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	request, err := tagService.client.buildRequest(ctx, "TagService.Create", method, contentType, body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +140,7 @@ func (tagService *TagService) Update(ctx context.Context, tagId uint64, tagParam
 	contentType := "application/json"
 	method := "PUT"
 	body := bytes.NewBuffer(tagJson)
-	request, err := tagService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	request, err := tagService.client.buildRequest(ctx, "TagService.Update", method, contentType, body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
@@ -170,7 +170,7 @@ func (tagService *TagService) Delete(ctx context.Context, tagId uint64) (bool, e
 	requestUrl := fmt.Sprintf(route, tagId)
 	contentType := "application/json"
 	method := "DELETE"
-	request, err := tagService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	request, err := tagService.client.buildRequest(ctx, "TagService.Delete", method, contentType, nil, requestUrl)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,151 @@
+package gointelowl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrTLPViolation is returned when an analysis would send data to a connector whose MaximumTlp is lower than the analysis' TLP.
+var ErrTLPViolation = errors.New("TLP violation")
+
+// TLPAction represents what a TLPPolicy does with the connectors that cannot receive the analysis' data.
+type TLPAction string
+
+// Values of the TLPAction enum.
+const (
+	// BlockTLPViolations refuses to submit the analysis
+	BlockTLPViolations TLPAction = "block"
+	// StripTLPViolations removes the offending connectors from the analysis
+	StripTLPViolations TLPAction = "strip"
+)
+
+// TLPViolation represents a connector that cannot receive the analysis' data.
+type TLPViolation struct {
+	Connector  string `json:"connector"`
+	MaximumTlp TLP    `json:"maximum_tlp"`
+	Reason     string `json:"reason"`
+}
+
+// TLPDecision is the auditable record of a TLPPolicy check.
+type TLPDecision struct {
+	Time   time.Time `json:"time"`
+	Tlp    TLP       `json:"tlp"`
+	Action TLPAction `json:"action"`
+	// Requested are the connectors requested, empty means every connector
+	Requested []string `json:"requested"`
+	// Allowed are the connectors that can receive the data
+	Allowed []string `json:"allowed"`
+	// Violations are the connectors that cannot receive the data
+	Violations []TLPViolation `json:"violations"`
+	// Blocked is set when the analysis must not be submitted
+	Blocked bool `json:"blocked"`
+	// Stripped is set when the offending connectors have been removed from the analysis
+	Stripped bool `json:"stripped"`
+}
+
+// TLPPolicy makes sure the data of an analysis never reaches a connector whose MaximumTlp is lower than the analysis' TLP.
+// It fails closed: unknown connectors and connectors without a valid MaximumTlp are violations.
+type TLPPolicy struct {
+	Action TLPAction
+	// Audit is called with every decision e.g: to log it for the compliance team
+	Audit      func(decision TLPDecision)
+	connectors []ConnectorConfig
+}
+
+// NewTLPPolicy makes a TLPPolicy out of the connector configurations.
+func NewTLPPolicy(connectorConfigs []ConnectorConfig, action TLPAction) *TLPPolicy {
+	return &TLPPolicy{
+		Action:     action,
+		connectors: connectorConfigs,
+	}
+}
+
+// NewTLPPolicy fetches the connector configurations of your IntelOwl instance to make a TLPPolicy.
+//
+//	Endpoint: GET /api/get_connector_configs
+func (client *IntelOwlClient) NewTLPPolicy(ctx context.Context, action TLPAction) (*TLPPolicy, error) {
+	connectorConfigs, err := client.ConnectorService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLPPolicy(*connectorConfigs, action), nil
+}
+
+// Enforce checks the connectors of an analysis against its TLP.
+// When no connector is requested, IntelOwl runs all of them: every enabled connector is checked then.
+// With StripTLPViolations the offending connectors are removed from the params' ConnectorsRequested;
+// if none would be left the analysis is blocked, as an empty list would ask for every connector.
+// The returned error wraps ErrTLPViolation when the analysis is blocked.
+func (tlpPolicy *TLPPolicy) Enforce(params *BasicAnalysisParams) (*TLPDecision, error) {
+	decision := TLPDecision{
+		Time:       time.Now().UTC(),
+		Tlp:        params.Tlp,
+		Action:     tlpPolicy.Action,
+		Requested:  append([]string{}, params.ConnectorsRequested...),
+		Allowed:    []string{},
+		Violations: []TLPViolation{},
+	}
+	connectors := map[string]ConnectorConfig{}
+	for _, connectorConfig := range tlpPolicy.connectors {
+		connectors[connectorConfig.Name] = connectorConfig
+	}
+	checked := params.ConnectorsRequested
+	if len(checked) == 0 {
+		for _, connectorConfig := range tlpPolicy.connectors {
+			if !connectorConfig.Disabled {
+				checked = append(checked, connectorConfig.Name)
+			}
+		}
+	}
+
+	tlp := params.Tlp
+	if tlp == 0 {
+		// * an unset TLP is sent as WHITE
+		tlp = WHITE
+	}
+	for _, connectorName := range checked {
+		connectorConfig, ok := connectors[connectorName]
+		switch {
+		case !ok:
+			decision.Violations = append(decision.Violations, TLPViolation{
+				Connector: connectorName,
+				Reason:    fmt.Sprintf("the connector %s is unknown", connectorName),
+			})
+		case !connectorConfig.MaximumTlp.IsValid():
+			decision.Violations = append(decision.Violations, TLPViolation{
+				Connector: connectorName,
+				Reason:    fmt.Sprintf("the connector %s has no maximum TLP known to the client", connectorName),
+			})
+		case !tlp.AllowedBy(connectorConfig.MaximumTlp):
+			decision.Violations = append(decision.Violations, TLPViolation{
+				Connector:  connectorName,
+				MaximumTlp: connectorConfig.MaximumTlp,
+				Reason:     fmt.Sprintf("the connector %s accepts up to %s data, not %s", connectorName, connectorConfig.MaximumTlp, tlp),
+			})
+		default:
+			decision.Allowed = append(decision.Allowed, connectorName)
+		}
+	}
+
+	var err error
+	if len(decision.Violations) > 0 {
+		if tlpPolicy.Action == StripTLPViolations && len(decision.Allowed) > 0 {
+			params.ConnectorsRequested = append([]string{}, decision.Allowed...)
+			decision.Stripped = true
+		} else {
+			decision.Blocked = true
+			reasons := make([]string, 0, len(decision.Violations))
+			for _, violation := range decision.Violations {
+				reasons = append(reasons, violation.Reason)
+			}
+			err = fmt.Errorf("%w: %s", ErrTLPViolation, strings.Join(reasons, "; "))
+		}
+	}
+	if tlpPolicy.Audit != nil {
+		tlpPolicy.Audit(decision)
+	}
+	return &decision, err
+}
@@ -0,0 +1,133 @@
+package gointelowl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// tlsVersions maps the accepted IntelOwlClientOptions.MinTLSVersion values to their crypto/tls constant.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewTLSConfig builds the tls.Config described by the IntelOwlClientOptions:
+// the CA bundle in Certificate, the client certificate and key for mutual TLS,
+// the minimum TLS version and the pinned public keys.
+// It returns nil if none of them is set.
+func NewTLSConfig(options *IntelOwlClientOptions) (*tls.Config, error) {
+	if options.Certificate == "" && options.ClientCertificate == "" && options.ClientKey == "" &&
+		options.MinTLSVersion == "" && len(options.PinnedPublicKeys) == 0 {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	// * Adding the CA bundle
+	if options.Certificate != "" {
+		caBundle, err := os.ReadFile(options.Certificate)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read the certificate %s: %w", options.Certificate, err)
+		}
+		certPool, err := x509.SystemCertPool()
+		if err != nil || certPool == nil {
+			certPool = x509.NewCertPool()
+		}
+		if !certPool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("The certificate %s does not contain any valid PEM encoded certificate", options.Certificate)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	// * Adding the client certificate
+	if options.ClientCertificate != "" || options.ClientKey != "" {
+		if options.ClientCertificate == "" || options.ClientKey == "" {
+			return nil, errors.New("Both the client certificate and the client key are needed for mutual TLS")
+		}
+		clientCertificate, err := tls.LoadX509KeyPair(options.ClientCertificate, options.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load the client certificate %s and key %s: %w", options.ClientCertificate, options.ClientKey, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCertificate}
+	}
+
+	// * Setting the minimum TLS version
+	if options.MinTLSVersion != "" {
+		minVersion, ok := tlsVersions[options.MinTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf("Unknown minimum TLS version %s: use 1.0, 1.1, 1.2 or 1.3", options.MinTLSVersion)
+		}
+		tlsConfig.MinVersion = minVersion
+	}
+
+	// * Verifying the pinned public keys
+	if len(options.PinnedPublicKeys) > 0 {
+		pins := make([][]byte, 0, len(options.PinnedPublicKeys))
+		for _, pinnedPublicKey := range options.PinnedPublicKeys {
+			pin, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pinnedPublicKey, "sha256//"))
+			if err != nil || len(pin) != sha256.Size {
+				return nil, fmt.Errorf("The pinned public key %s is not a base64 encoded SHA-256 hash", pinnedPublicKey)
+			}
+			pins = append(pins, pin)
+		}
+		tlsConfig.VerifyPeerCertificate = verifyPinnedPublicKeys(pins)
+	}
+	return tlsConfig, nil
+}
+
+// verifyPinnedPublicKeys makes sure one of the certificates sent by the server has one of the pinned public keys.
+func verifyPinnedPublicKeys(pins [][]byte) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		certificates := []*x509.Certificate{}
+		for _, chain := range verifiedChains {
+			certificates = append(certificates, chain...)
+		}
+		if len(certificates) == 0 {
+			for _, rawCert := range rawCerts {
+				certificate, err := x509.ParseCertificate(rawCert)
+				if err != nil {
+					return err
+				}
+				certificates = append(certificates, certificate)
+			}
+		}
+		for _, certificate := range certificates {
+			publicKeyHash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+			for _, pin := range pins {
+				if bytes.Equal(publicKeyHash[:], pin) {
+					return nil
+				}
+			}
+		}
+		return errors.New("None of the server's certificates matches the pinned public keys")
+	}
+}
+
+// newHTTPClient makes the default http.Client used when none is passed to NewIntelOwlClient.
+func newHTTPClient(options *IntelOwlClientOptions, timeout time.Duration) (*http.Client, error) {
+	httpClient := &http.Client{
+		Timeout: timeout,
+	}
+	tlsConfig, err := NewTLSConfig(options)
+	if err != nil {
+		return httpClient, err
+	}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		httpClient.Transport = transport
+	}
+	return httpClient, nil
+}
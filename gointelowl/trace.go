@@ -0,0 +1,123 @@
+package gointelowl
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// redacted replaces the secrets in the traces.
+const redacted = "[REDACTED]"
+
+// defaultMaxDumpSize is the number of bytes of a body that are dumped when LoggerParams.MaxDumpSize is not set.
+const defaultMaxDumpSize = 4096
+
+// requestTracer logs every attempt at sending a request.
+type requestTracer struct {
+	logger      Logger
+	token       string
+	dumpBodies  bool
+	maxDumpSize int
+}
+
+// newRequestTracer configures the requestTracer through the LoggerParams, falling back to the IntelOwlLogger.
+func newRequestTracer(loggerParams *LoggerParams, intelOwlLogger *IntelOwlLogger, token string) *requestTracer {
+	tracer := &requestTracer{
+		logger:      loggerParams.Logger,
+		token:       token,
+		dumpBodies:  loggerParams.DumpBodies,
+		maxDumpSize: loggerParams.MaxDumpSize,
+	}
+	if tracer.logger == nil {
+		tracer.logger = intelOwlLogger
+	}
+	if tracer.maxDumpSize <= 0 {
+		tracer.maxDumpSize = defaultMaxDumpSize
+	}
+	return tracer
+}
+
+// redact hides the token wherever it appears.
+func (tracer *requestTracer) redact(text string) string {
+	if tracer.token == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, tracer.token, redacted)
+}
+
+// dump redacts and truncates a body.
+// Redacting comes first so that no part of the token survives the truncation.
+func (tracer *requestTracer) dump(body []byte) string {
+	dumpedBody := tracer.redact(string(body))
+	if len(dumpedBody) > tracer.maxDumpSize {
+		return dumpedBody[:tracer.maxDumpSize] + "...(truncated)"
+	}
+	return dumpedBody
+}
+
+// dumpHeaders redacts the Authorization header and flattens the headers.
+func (tracer *requestTracer) dumpHeaders(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for name, values := range header {
+		if name == "Authorization" {
+			headers[name] = redacted
+			continue
+		}
+		headers[name] = tracer.redact(strings.Join(values, ", "))
+	}
+	return headers
+}
+
+// dumpRequestBody reads the beginning of a copy of the request body, if the body can be copied.
+// Multipart bodies are not dumped: they carry the files being analyzed and are streamed from them.
+func (tracer *requestTracer) dumpRequestBody(request *http.Request) string {
+	if request.GetBody == nil || strings.HasPrefix(request.Header.Get("Content-Type"), "multipart/") {
+		return ""
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	// * reading enough to redact a token crossing the limit and to know if the body has to be truncated
+	bodyBytes, _ := ioutil.ReadAll(io.LimitReader(body, int64(tracer.maxDumpSize+len(tracer.token))+1))
+	return tracer.dump(bodyBytes)
+}
+
+// trace logs an attempt at sending a request.
+// response and responseBody are nil if no response was received.
+// responseBody is also nil when the response is streamed, its size is then the Content-Length.
+func (tracer *requestTracer) trace(request *http.Request, attempt int, start time.Time, response *http.Response, responseBody []byte, err error) {
+	if tracer == nil || tracer.logger == nil {
+		return
+	}
+	fields := LogFields{
+		"operation":    operationFromContext(request.Context()),
+		"method":       request.Method,
+		"url":          tracer.redact(request.URL.String()),
+		"attempt":      attempt,
+		"latency":      time.Since(start).String(),
+		"request_size": request.ContentLength,
+	}
+	if response != nil {
+		fields["status"] = response.StatusCode
+		if responseBody != nil {
+			fields["response_size"] = len(responseBody)
+		} else {
+			fields["response_size"] = response.ContentLength
+		}
+	}
+	if err != nil {
+		fields["error"] = tracer.redact(err.Error())
+	}
+	if tracer.dumpBodies {
+		fields["request_headers"] = tracer.dumpHeaders(request.Header)
+		fields["request_body"] = tracer.dumpRequestBody(request)
+		if response != nil {
+			fields["response_body"] = tracer.dump(responseBody)
+		}
+	}
+	tracer.logger.Debug("IntelOwl request", fields)
+}
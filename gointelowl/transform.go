@@ -0,0 +1,153 @@
+package gointelowl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TransformFunc transforms a string found in a job e.g: defanging the indicators it contains.
+type TransformFunc func(value string) string
+
+// identity copies the values that are not transformed.
+func identity(value string) string {
+	return value
+}
+
+// TransformValue returns a deep copy of a decoded JSON value where every string, map keys included, is transformed.
+// Maps, slices and strings are walked through while numbers, booleans and nil are copied as they are.
+func TransformValue(value interface{}, transform TransformFunc) interface{} {
+	switch typedValue := value.(type) {
+	case string:
+		return transform(typedValue)
+	case map[string]interface{}:
+		return TransformMap(typedValue, transform)
+	case []interface{}:
+		transformedSlice := make([]interface{}, len(typedValue))
+		for index, item := range typedValue {
+			transformedSlice[index] = TransformValue(item, transform)
+		}
+		return transformedSlice
+	case []string:
+		return transformStrings(typedValue, transform)
+	}
+	return value
+}
+
+// TransformMap returns a deep copy of a decoded JSON object where every key and every string value is transformed.
+// No entry is ever dropped: when a key is transformed into one that is already taken, e.g: "evil.com" defanged next
+// to an "evil[.]com" key, it gets the first free " (2)", " (3)", ... suffix. The keys that the transformation leaves
+// as they are keep their name, then the others are placed in the sorted order of their original keys.
+func TransformMap(value map[string]interface{}, transform TransformFunc) map[string]interface{} {
+	if value == nil {
+		return nil
+	}
+	transformedKeys := make(map[string]string, len(value))
+	targets := make(map[string]int, len(value))
+	for key := range value {
+		transformedKeys[key] = transform(key)
+		targets[transformedKeys[key]]++
+	}
+	if len(targets) < len(value) {
+		resolveKeyCollisions(transformedKeys)
+	}
+	transformedMap := make(map[string]interface{}, len(value))
+	for key, item := range value {
+		transformedMap[transformedKeys[key]] = TransformValue(item, transform)
+	}
+	return transformedMap
+}
+
+// resolveKeyCollisions renames the transformed keys that collide so that every key is unique.
+func resolveKeyCollisions(transformedKeys map[string]string) {
+	keys := make([]string, 0, len(transformedKeys))
+	for key := range transformedKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	taken := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if transformedKeys[key] == key {
+			taken[key] = true
+		}
+	}
+	for _, key := range keys {
+		transformedKey := transformedKeys[key]
+		if transformedKey == key {
+			continue
+		}
+		uniqueKey := transformedKey
+		for suffix := 2; taken[uniqueKey]; suffix++ {
+			uniqueKey = fmt.Sprintf("%s (%d)", transformedKey, suffix)
+		}
+		taken[uniqueKey] = true
+		transformedKeys[key] = uniqueKey
+	}
+}
+
+// transformStrings returns a transformed copy of a slice of strings.
+func transformStrings(values []string, transform TransformFunc) []string {
+	if values == nil {
+		return nil
+	}
+	transformedStrings := make([]string, len(values))
+	for index, value := range values {
+		transformedStrings[index] = transform(value)
+	}
+	return transformedStrings
+}
+
+// Transform returns a copy of the report where every key and string value of the Report and the Errors are transformed.
+func (report *Report) Transform(transform TransformFunc) Report {
+	transformedReport := *report
+	transformedReport.Report = TransformMap(report.Report, transform)
+	transformedReport.Errors = transformStrings(report.Errors, transform)
+	transformedReport.RuntimeConfiguration = TransformMap(report.RuntimeConfiguration, identity)
+	return transformedReport
+}
+
+// Transform returns a deep copy of the job where the ObservableName and every key and string value of the analyzer and
+// connector reports are transformed, leaving the original job untouched.
+// e.g: job.Transform(ioc.Defang) makes a job that is safe to paste into a ticket.
+func (job *Job) Transform(transform TransformFunc) *Job {
+	transformedJob := *job
+	transformedJob.BaseJob = job.BaseJob.copy()
+	transformedJob.ObservableName = transform(job.ObservableName)
+	transformedJob.AnalyzerReports = transformReports(job.AnalyzerReports, transform)
+	transformedJob.ConnectorReports = transformReports(job.ConnectorReports, transform)
+	transformedJob.Permission = TransformMap(job.Permission, identity)
+	return &transformedJob
+}
+
+// Transform returns a copy of the job list entry where the ObservableName is transformed.
+func (jobList *JobList) Transform(transform TransformFunc) JobList {
+	transformedJobList := JobList{
+		BaseJob: jobList.BaseJob.copy(),
+	}
+	transformedJobList.ObservableName = transform(jobList.ObservableName)
+	return transformedJobList
+}
+
+// transformReports transforms every report of a slice.
+func transformReports(reports []Report, transform TransformFunc) []Report {
+	if reports == nil {
+		return nil
+	}
+	transformedReports := make([]Report, len(reports))
+	for index := range reports {
+		transformedReports[index] = reports[index].Transform(transform)
+	}
+	return transformedReports
+}
+
+// copy makes a copy of the BaseJob that does not share its slices with the original.
+func (baseJob BaseJob) copy() BaseJob {
+	if baseJob.Tags != nil {
+		baseJob.Tags = append([]Tag{}, baseJob.Tags...)
+	}
+	baseJob.AnalyzersRequested = transformStrings(baseJob.AnalyzersRequested, identity)
+	baseJob.ConnectorsRequested = transformStrings(baseJob.ConnectorsRequested, identity)
+	baseJob.AnalyzersToExecute = transformStrings(baseJob.AnalyzersToExecute, identity)
+	baseJob.ConnectorsToExecute = transformStrings(baseJob.ConnectorsToExecute, identity)
+	baseJob.Errors = transformStrings(baseJob.Errors, identity)
+	return baseJob
+}
@@ -0,0 +1,258 @@
+package gointelowl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SkipReason represents why IntelOwl would skip a requested plugin.
+type SkipReason string
+
+// Values of the SkipReason enum.
+const (
+	// SkipUnknown means there is no plugin with that name
+	SkipUnknown SkipReason = "unknown"
+	// SkipDisabled means the plugin is disabled
+	SkipDisabled SkipReason = "disabled"
+	// SkipNotConfigured means the plugin is missing secrets or configuration
+	SkipNotConfigured SkipReason = "not_configured"
+	// SkipWrongType means a file analyzer was requested for an observable or the other way round
+	SkipWrongType SkipReason = "wrong_type"
+	// SkipUnsupportedObservable means the analyzer does not support the observable's classification
+	SkipUnsupportedObservable SkipReason = "unsupported_observable"
+	// SkipUnsupportedFiletype means the analyzer does not support the file's mimetype
+	SkipUnsupportedFiletype SkipReason = "unsupported_filetype"
+)
+
+// Types of plugins.
+const (
+	AnalyzerPluginType  = "analyzer"
+	ConnectorPluginType = "connector"
+)
+
+// SkippedPlugin represents a requested plugin that IntelOwl would skip.
+type SkippedPlugin struct {
+	Name       string     `json:"name"`
+	PluginType string     `json:"plugin_type"`
+	Reason     SkipReason `json:"reason"`
+	Detail     string     `json:"detail"`
+}
+
+// ValidationReport represents the outcome of validating the plugins requested by an analysis.
+type ValidationReport struct {
+	// Analyzers and Connectors are the requested plugins that would run
+	Analyzers  []string `json:"analyzers"`
+	Connectors []string `json:"connectors"`
+	// Skipped are the requested plugins that would not run
+	Skipped []SkippedPlugin `json:"skipped"`
+	// Pruned is set when the skipped plugins have been removed from the params
+	Pruned bool `json:"pruned"`
+}
+
+// Valid reports whether every requested plugin would run.
+func (validationReport *ValidationReport) Valid() bool {
+	return len(validationReport.Skipped) == 0
+}
+
+// PluginValidationError is returned when a validation fails: it carries the ValidationReport.
+type PluginValidationError struct {
+	Report  *ValidationReport
+	Message string
+}
+
+// Error lets you implement the error interface.
+func (validationError *PluginValidationError) Error() string {
+	reasons := make([]string, 0, len(validationError.Report.Skipped))
+	for _, skippedPlugin := range validationError.Report.Skipped {
+		reasons = append(reasons, skippedPlugin.Detail)
+	}
+	return fmt.Sprintf("%s: %s", validationError.Message, strings.Join(reasons, "; "))
+}
+
+// ValidationMode represents what a PluginValidator does with the plugins that would be skipped.
+type ValidationMode int
+
+// Values of the ValidationMode enum.
+const (
+	// ReportSkipped only reports the plugins that would be skipped
+	ReportSkipped ValidationMode = iota
+	// FailOnSkipped returns a PluginValidationError if any plugin would be skipped
+	FailOnSkipped
+	// PruneSkipped removes the plugins that would be skipped from the params
+	PruneSkipped
+)
+
+// PluginValidator checks the analyzers and connectors requested by an analysis against their configurations
+// before it is submitted, as IntelOwl would otherwise drop the unsuitable ones with nothing but a warning.
+type PluginValidator struct {
+	Mode       ValidationMode
+	analyzers  map[string]AnalyzerConfig
+	connectors map[string]ConnectorConfig
+}
+
+// NewPluginValidator makes a PluginValidator out of the analyzer and connector configurations.
+func NewPluginValidator(analyzerConfigs []AnalyzerConfig, connectorConfigs []ConnectorConfig, mode ValidationMode) *PluginValidator {
+	pluginValidator := &PluginValidator{
+		Mode:       mode,
+		analyzers:  map[string]AnalyzerConfig{},
+		connectors: map[string]ConnectorConfig{},
+	}
+	for _, analyzerConfig := range analyzerConfigs {
+		pluginValidator.analyzers[analyzerConfig.Name] = analyzerConfig
+	}
+	for _, connectorConfig := range connectorConfigs {
+		pluginValidator.connectors[connectorConfig.Name] = connectorConfig
+	}
+	return pluginValidator
+}
+
+// NewPluginValidator fetches the analyzer and connector configurations of your IntelOwl instance to make a PluginValidator.
+//
+//	Endpoints: GET /api/get_analyzer_configs and GET /api/get_connector_configs
+func (client *IntelOwlClient) NewPluginValidator(ctx context.Context, mode ValidationMode) (*PluginValidator, error) {
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	connectorConfigs, err := client.ConnectorService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewPluginValidator(*analyzerConfigs, *connectorConfigs, mode), nil
+}
+
+// ValidateObservable checks the plugins requested to analyze an observable.
+// An empty classification is filled in with ClassifyObservable for the check, the params are only modified when pruning.
+func (pluginValidator *PluginValidator) ValidateObservable(params *ObservableAnalysisParams) (*ValidationReport, error) {
+	classification := params.ObservableClassification
+	if classification == "" {
+		classification = ClassifyObservable(params.ObservableName)
+	}
+	checkAnalyzer := func(analyzerConfig AnalyzerConfig) (SkipReason, string) {
+		if analyzerConfig.Type != "observable" {
+			return SkipWrongType, fmt.Sprintf("%s analyzes files, not observables", analyzerConfig.Name)
+		}
+		if !analyzerConfig.SupportsObservable(classification) {
+			return SkipUnsupportedObservable, fmt.Sprintf("%s does not support %s observables", analyzerConfig.Name, classification)
+		}
+		return "", ""
+	}
+	return pluginValidator.validate(&params.BasicAnalysisParams, checkAnalyzer)
+}
+
+// ValidateFile checks the plugins requested to analyze a file with the given mimetype e.g: "application/pdf".
+// An empty mimetype falls back to the Source's ContentType; if it is unknown the filetypes are not checked.
+// The params are only modified when pruning.
+func (pluginValidator *PluginValidator) ValidateFile(params *FileAnalysisParams, mimetype string) (*ValidationReport, error) {
+	if mimetype == "" && params.Source != nil {
+		mimetype = params.Source.ContentType
+	}
+	checkAnalyzer := func(analyzerConfig AnalyzerConfig) (SkipReason, string) {
+		if analyzerConfig.Type != "file" {
+			return SkipWrongType, fmt.Sprintf("%s analyzes observables, not files", analyzerConfig.Name)
+		}
+		if mimetype == "" {
+			return "", ""
+		}
+		if !analyzerConfig.SupportsMimetype(mimetype) {
+			return SkipUnsupportedFiletype, fmt.Sprintf("%s does not support %s files", analyzerConfig.Name, mimetype)
+		}
+		return "", ""
+	}
+	return pluginValidator.validate(&params.BasicAnalysisParams, checkAnalyzer)
+}
+
+// validate checks every requested plugin and applies the ValidationMode.
+func (pluginValidator *PluginValidator) validate(params *BasicAnalysisParams, checkAnalyzer func(analyzerConfig AnalyzerConfig) (SkipReason, string)) (*ValidationReport, error) {
+	validationReport := &ValidationReport{
+		Analyzers:  []string{},
+		Connectors: []string{},
+		Skipped:    []SkippedPlugin{},
+	}
+	for _, analyzerName := range params.AnalyzersRequested {
+		analyzerConfig, ok := pluginValidator.analyzers[analyzerName]
+		if !ok {
+			validationReport.skip(analyzerName, AnalyzerPluginType, SkipUnknown, fmt.Sprintf("there is no analyzer named %s", analyzerName))
+			continue
+		}
+		if reason, detail := checkPlugin(&analyzerConfig.BaseConfigurationType, AnalyzerPluginType); reason != "" {
+			validationReport.skip(analyzerName, AnalyzerPluginType, reason, detail)
+			continue
+		}
+		if reason, detail := checkAnalyzer(analyzerConfig); reason != "" {
+			validationReport.skip(analyzerName, AnalyzerPluginType, reason, detail)
+			continue
+		}
+		validationReport.Analyzers = append(validationReport.Analyzers, analyzerName)
+	}
+	for _, connectorName := range params.ConnectorsRequested {
+		connectorConfig, ok := pluginValidator.connectors[connectorName]
+		if !ok {
+			validationReport.skip(connectorName, ConnectorPluginType, SkipUnknown, fmt.Sprintf("there is no connector named %s", connectorName))
+			continue
+		}
+		if reason, detail := checkPlugin(&connectorConfig.BaseConfigurationType, ConnectorPluginType); reason != "" {
+			validationReport.skip(connectorName, ConnectorPluginType, reason, detail)
+			continue
+		}
+		validationReport.Connectors = append(validationReport.Connectors, connectorName)
+	}
+
+	if validationReport.Valid() {
+		return validationReport, nil
+	}
+	switch pluginValidator.Mode {
+	case FailOnSkipped:
+		return validationReport, &PluginValidationError{Report: validationReport, Message: "Some of the requested plugins would be skipped"}
+	case PruneSkipped:
+		// * an empty list asks IntelOwl to run every plugin: that is not what pruning is for
+		if len(params.AnalyzersRequested) > 0 && len(validationReport.Analyzers) == 0 {
+			return validationReport, &PluginValidationError{Report: validationReport, Message: "None of the requested analyzers would run"}
+		}
+		if len(params.ConnectorsRequested) > 0 && len(validationReport.Connectors) == 0 {
+			return validationReport, &PluginValidationError{Report: validationReport, Message: "None of the requested connectors would run"}
+		}
+		params.AnalyzersRequested = append([]string{}, validationReport.Analyzers...)
+		params.ConnectorsRequested = append([]string{}, validationReport.Connectors...)
+		validationReport.Pruned = true
+	}
+	return validationReport, nil
+}
+
+// checkPlugin checks what analyzers and connectors have in common: being enabled and configured.
+func checkPlugin(config *BaseConfigurationType, pluginType string) (SkipReason, string) {
+	if config.Disabled {
+		return SkipDisabled, fmt.Sprintf("the %s %s is disabled", pluginType, config.Name)
+	}
+	if !config.Verification.Configured {
+		detail := fmt.Sprintf("the %s %s is not configured", pluginType, config.Name)
+		if len(config.Verification.MissingSecrets) > 0 {
+			detail += fmt.Sprintf(" (missing secrets: %s)", strings.Join(config.Verification.MissingSecrets, ", "))
+		} else if config.Verification.ErrorMessage != "" {
+			detail += fmt.Sprintf(" (%s)", config.Verification.ErrorMessage)
+		}
+		return SkipNotConfigured, detail
+	}
+	return "", ""
+}
+
+// skip records a plugin that would be skipped.
+func (validationReport *ValidationReport) skip(name string, pluginType string, reason SkipReason, detail string) {
+	validationReport.Skipped = append(validationReport.Skipped, SkippedPlugin{
+		Name:       name,
+		PluginType: pluginType,
+		Reason:     reason,
+		Detail:     detail,
+	})
+}
+
+// containsString reports whether the value is one of the values.
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package gointelowl
+
+import (
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"time"
+)
+
+// Signatures and flags of the zip format.
+// Zip docs: https://pkware.cachefly.net/webdocs/casestudies/APPNOTE.TXT
+const (
+	zipLocalFileHeaderSignature  = 0x04034b50
+	zipDataDescriptorSignature   = 0x08074b50
+	zipCentralDirectorySignature = 0x02014b50
+	zipEndOfCentralDirSignature  = 0x06054b50
+	zipVersion                   = 20
+	zipMethodDeflate             = 8
+	// encrypted, sizes in the data descriptor, UTF-8 name
+	zipFlags                   = 0x1 | 0x8 | 0x800
+	zipCryptoHeaderSize        = 12
+	zipCryptoMultiplier        = 134775813
+	zipCryptoKey0       uint32 = 0x12345678
+	zipCryptoKey1       uint32 = 0x23456789
+	zipCryptoKey2       uint32 = 0x34567890
+)
+
+// errZipTooLarge is returned when the entry does not fit in a zip archive without the zip64 extensions.
+var errZipTooLarge = errors.New("The sample is too large to be zipped")
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (countingWriter *countingWriter) Write(data []byte) (int, error) {
+	written, err := countingWriter.writer.Write(data)
+	countingWriter.count += int64(written)
+	return written, err
+}
+
+// zipCryptoWriter encrypts the data written through it with the traditional PKWARE encryption (ZipCrypto).
+type zipCryptoWriter struct {
+	writer io.Writer
+	keys   [3]uint32
+}
+
+// newZipCryptoWriter initializes the encryption keys with the password.
+func newZipCryptoWriter(writer io.Writer, password string) *zipCryptoWriter {
+	zipCrypto := &zipCryptoWriter{
+		writer: writer,
+		keys:   [3]uint32{zipCryptoKey0, zipCryptoKey1, zipCryptoKey2},
+	}
+	for _, passwordByte := range []byte(password) {
+		zipCrypto.updateKeys(passwordByte)
+	}
+	return zipCrypto
+}
+
+// crc32Update updates a CRC-32 with a single byte, the way the ZipCrypto key schedule needs it.
+func crc32Update(crc uint32, data byte) uint32 {
+	return crc32.IEEETable[byte(crc)^data] ^ (crc >> 8)
+}
+
+func (zipCrypto *zipCryptoWriter) updateKeys(plainByte byte) {
+	zipCrypto.keys[0] = crc32Update(zipCrypto.keys[0], plainByte)
+	zipCrypto.keys[1] = (zipCrypto.keys[1]+(zipCrypto.keys[0]&0xff))*zipCryptoMultiplier + 1
+	zipCrypto.keys[2] = crc32Update(zipCrypto.keys[2], byte(zipCrypto.keys[1]>>24))
+}
+
+func (zipCrypto *zipCryptoWriter) keyStreamByte() byte {
+	temp := uint16(zipCrypto.keys[2] | 2)
+	return byte((temp * (temp ^ 1)) >> 8)
+}
+
+func (zipCrypto *zipCryptoWriter) Write(data []byte) (int, error) {
+	encrypted := make([]byte, len(data))
+	for index, plainByte := range data {
+		encrypted[index] = plainByte ^ zipCrypto.keyStreamByte()
+		zipCrypto.updateKeys(plainByte)
+	}
+	return zipCrypto.writer.Write(encrypted)
+}
+
+// encryptedZipWriter streams a single entry into a password protected zip archive.
+// The entry is deflated and encrypted with ZipCrypto, the format used to share malware samples.
+type encryptedZipWriter struct {
+	archive   *countingWriter
+	encrypted *countingWriter
+	deflater  *flate.Writer
+	crc       hash.Hash32
+	size      int64
+	name      string
+	dosTime   uint16
+	dosDate   uint16
+}
+
+// msDosTimeDate converts a time into the MS-DOS time and date used by the zip format.
+func msDosTimeDate(modified time.Time) (uint16, uint16) {
+	if modified.Year() < 1980 {
+		modified = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	dosTime := uint16(modified.Hour()<<11 | modified.Minute()<<5 | modified.Second()>>1)
+	dosDate := uint16((modified.Year()-1980)<<9 | int(modified.Month())<<5 | modified.Day())
+	return dosTime, dosDate
+}
+
+// newEncryptedZipWriter writes the entry's header and returns the writer the entry's content is written to.
+// Close must be called to finish the archive.
+func newEncryptedZipWriter(writer io.Writer, name string, password string, modified time.Time) (*encryptedZipWriter, error) {
+	zipWriter := &encryptedZipWriter{
+		archive: &countingWriter{writer: writer},
+		crc:     crc32.NewIEEE(),
+		name:    name,
+	}
+	zipWriter.dosTime, zipWriter.dosDate = msDosTimeDate(modified)
+
+	// * Writing the local file header, the sizes and CRC-32 come later in the data descriptor
+	header := make([]byte, 30)
+	binary.LittleEndian.PutUint32(header[0:], zipLocalFileHeaderSignature)
+	binary.LittleEndian.PutUint16(header[4:], zipVersion)
+	binary.LittleEndian.PutUint16(header[6:], zipFlags)
+	binary.LittleEndian.PutUint16(header[8:], zipMethodDeflate)
+	binary.LittleEndian.PutUint16(header[10:], zipWriter.dosTime)
+	binary.LittleEndian.PutUint16(header[12:], zipWriter.dosDate)
+	binary.LittleEndian.PutUint16(header[26:], uint16(len(name)))
+	if _, err := zipWriter.archive.Write(append(header, name...)); err != nil {
+		return nil, err
+	}
+
+	// * Writing the encryption header, its last byte lets unzippers check the password
+	zipWriter.encrypted = &countingWriter{writer: zipWriter.archive}
+	zipCrypto := newZipCryptoWriter(zipWriter.encrypted, password)
+	encryptionHeader := make([]byte, zipCryptoHeaderSize)
+	if _, err := rand.Read(encryptionHeader[:zipCryptoHeaderSize-1]); err != nil {
+		return nil, err
+	}
+	encryptionHeader[zipCryptoHeaderSize-1] = byte(zipWriter.dosTime >> 8)
+	if _, err := zipCrypto.Write(encryptionHeader); err != nil {
+		return nil, err
+	}
+
+	deflater, err := flate.NewWriter(zipCrypto, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	zipWriter.deflater = deflater
+	return zipWriter, nil
+}
+
+func (zipWriter *encryptedZipWriter) Write(data []byte) (int, error) {
+	zipWriter.crc.Write(data)
+	zipWriter.size += int64(len(data))
+	return zipWriter.deflater.Write(data)
+}
+
+// Close writes the data descriptor, the central directory and the end of central directory record.
+func (zipWriter *encryptedZipWriter) Close() error {
+	if err := zipWriter.deflater.Close(); err != nil {
+		return err
+	}
+	compressedSize := zipWriter.encrypted.count
+	if zipWriter.size >= math.MaxUint32 || compressedSize >= math.MaxUint32 {
+		return errZipTooLarge
+	}
+	crc := zipWriter.crc.Sum32()
+
+	dataDescriptor := make([]byte, 16)
+	binary.LittleEndian.PutUint32(dataDescriptor[0:], zipDataDescriptorSignature)
+	binary.LittleEndian.PutUint32(dataDescriptor[4:], crc)
+	binary.LittleEndian.PutUint32(dataDescriptor[8:], uint32(compressedSize))
+	binary.LittleEndian.PutUint32(dataDescriptor[12:], uint32(zipWriter.size))
+	if _, err := zipWriter.archive.Write(dataDescriptor); err != nil {
+		return err
+	}
+
+	centralDirectoryOffset := zipWriter.archive.count
+	centralDirectory := make([]byte, 46)
+	binary.LittleEndian.PutUint32(centralDirectory[0:], zipCentralDirectorySignature)
+	binary.LittleEndian.PutUint16(centralDirectory[4:], zipVersion)
+	binary.LittleEndian.PutUint16(centralDirectory[6:], zipVersion)
+	binary.LittleEndian.PutUint16(centralDirectory[8:], zipFlags)
+	binary.LittleEndian.PutUint16(centralDirectory[10:], zipMethodDeflate)
+	binary.LittleEndian.PutUint16(centralDirectory[12:], zipWriter.dosTime)
+	binary.LittleEndian.PutUint16(centralDirectory[14:], zipWriter.dosDate)
+	binary.LittleEndian.PutUint32(centralDirectory[16:], crc)
+	binary.LittleEndian.PutUint32(centralDirectory[20:], uint32(compressedSize))
+	binary.LittleEndian.PutUint32(centralDirectory[24:], uint32(zipWriter.size))
+	binary.LittleEndian.PutUint16(centralDirectory[28:], uint16(len(zipWriter.name)))
+	// * the local file header is the first thing in the archive: its offset stays 0
+	if _, err := zipWriter.archive.Write(append(centralDirectory, zipWriter.name...)); err != nil {
+		return err
+	}
+	centralDirectorySize := zipWriter.archive.count - centralDirectoryOffset
+
+	endOfCentralDirectory := make([]byte, 22)
+	binary.LittleEndian.PutUint32(endOfCentralDirectory[0:], zipEndOfCentralDirSignature)
+	binary.LittleEndian.PutUint16(endOfCentralDirectory[8:], 1)
+	binary.LittleEndian.PutUint16(endOfCentralDirectory[10:], 1)
+	binary.LittleEndian.PutUint32(endOfCentralDirectory[12:], uint32(centralDirectorySize))
+	binary.LittleEndian.PutUint32(endOfCentralDirectory[16:], uint32(centralDirectoryOffset))
+	_, err := zipWriter.archive.Write(endOfCentralDirectory)
+	return err
+}
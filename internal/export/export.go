@@ -0,0 +1,53 @@
+// Package export holds what the stix and misp exporters share: deterministic identifiers and naming of jobs.
+package export
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+// URLNamespace is the namespace of the UUIDs made out of URLs, see RFC 4122.
+var URLNamespace = MustParseUUID("6ba7b811-9dad-11d1-80b4-00c04fd430c8")
+
+// UUIDv5 makes a name based UUID, version 5.
+func UUIDv5(namespace [16]byte, name string) [16]byte {
+	hash := sha1.New()
+	hash.Write(namespace[:])
+	hash.Write([]byte(name))
+	var uuid [16]byte
+	copy(uuid[:], hash.Sum(nil))
+	uuid[6] = (uuid[6] & 0x0f) | 0x50
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return uuid
+}
+
+// FormatUUID writes a UUID in its canonical textual form.
+func FormatUUID(uuid [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+// MustParseUUID parses a UUID in its canonical textual form.
+func MustParseUUID(text string) [16]byte {
+	var uuid [16]byte
+	decoded, err := hex.DecodeString(strings.ReplaceAll(text, "-", ""))
+	if err != nil || len(decoded) != len(uuid) {
+		panic("Invalid UUID " + text)
+	}
+	copy(uuid[:], decoded)
+	return uuid
+}
+
+// ObservableName is what a job has analyzed: the observable or the file name, the MD5 of unnamed files.
+func ObservableName(job *gointelowl.Job) string {
+	if job.IsSample {
+		if job.FileName != "" {
+			return job.FileName
+		}
+		return job.Md5
+	}
+	return job.ObservableName
+}
@@ -0,0 +1,336 @@
+// Package ioc extracts indicators of compromise from free text such as incident notes, emails and logs,
+// ready to be analyzed by IntelOwl.
+package ioc
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+// Kind represents the kind of an extracted indicator.
+type Kind string
+
+// Values of the Kind enum.
+const (
+	IP     Kind = "ip"
+	Domain Kind = "domain"
+	URL    Kind = "url"
+	Hash   Kind = "hash"
+	Email  Kind = "email"
+)
+
+// Indicator represents an indicator of compromise found in a text.
+type Indicator struct {
+	Kind  Kind   `json:"kind"`
+	Value string `json:"value"`
+	// Classification is the classification IntelOwl gives to the Value, see gointelowl.ClassifyObservable
+	Classification string `json:"classification"`
+}
+
+// DefaultAllowlistedNetworks are the private, loopback, link-local, multicast and reserved ranges.
+var DefaultAllowlistedNetworks = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"2001:db8::/32",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// DefaultAllowlistedDomains are the domains reserved for documentation and local use.
+var DefaultAllowlistedDomains = []string{
+	"example.com",
+	"example.net",
+	"example.org",
+	"localhost",
+	"local",
+	"invalid",
+	"test",
+}
+
+// ExtractorOptions represents the configuration of an Extractor.
+type ExtractorOptions struct {
+	// AllowlistedNetworks are the CIDR ranges whose addresses are not extracted
+	AllowlistedNetworks []string `json:"allowlisted_networks"`
+	// AllowlistedDomains are the benign domains: they, their subdomains and the URLs and emails on them are not extracted
+	AllowlistedDomains []string `json:"allowlisted_domains"`
+	// Kinds are the kinds of indicators extracted, empty means all of them
+	Kinds []Kind `json:"kinds"`
+}
+
+// DefaultExtractorOptions returns ExtractorOptions that skip private ranges and reserved domains.
+func DefaultExtractorOptions() *ExtractorOptions {
+	return &ExtractorOptions{
+		AllowlistedNetworks: append([]string{}, DefaultAllowlistedNetworks...),
+		AllowlistedDomains:  append([]string{}, DefaultAllowlistedDomains...),
+	}
+}
+
+// Extractor finds the indicators of compromise in free text.
+type Extractor struct {
+	allowlistedNetworks []*net.IPNet
+	allowlistedDomains  map[string]bool
+	kinds               map[Kind]bool
+}
+
+// Patterns of the indicators, matched against refanged text.
+var (
+	urlPattern    = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s<>"'\x60{}|\\^\[\]()]+`)
+	emailPattern  = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}\b`)
+	ipv4Pattern   = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern   = regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`)
+	hashPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{32,128}\b`)
+	domainPattern = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}\b`)
+)
+
+// fileExtensions are the "top level domains" that are far more likely to be file names e.g: "invoice.pdf".
+var fileExtensions = map[string]bool{
+	"bat": true, "bin": true, "cfg": true, "csv": true, "dat": true, "dll": true, "doc": true, "docm": true,
+	"docx": true, "exe": true, "gif": true, "hta": true, "htm": true, "html": true, "ini": true, "iso": true,
+	"jar": true, "jpeg": true, "jpg": true, "js": true, "json": true, "lnk": true, "log": true, "msi": true,
+	"pdf": true, "php": true, "png": true, "ps1": true, "py": true, "rar": true, "sh": true, "sys": true,
+	"tmp": true, "txt": true, "vbs": true, "xls": true, "xlsm": true, "xlsx": true, "xml": true, "yaml": true,
+	"yml": true,
+}
+
+// NewExtractor makes an Extractor, nil options means DefaultExtractorOptions.
+func NewExtractor(options *ExtractorOptions) (*Extractor, error) {
+	if options == nil {
+		options = DefaultExtractorOptions()
+	}
+	extractor := &Extractor{
+		allowlistedDomains: map[string]bool{},
+		kinds:              map[Kind]bool{},
+	}
+	for _, network := range options.AllowlistedNetworks {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid allowlisted network %s: %w", network, err)
+		}
+		extractor.allowlistedNetworks = append(extractor.allowlistedNetworks, ipNet)
+	}
+	for _, domain := range options.AllowlistedDomains {
+		extractor.allowlistedDomains[strings.Trim(strings.ToLower(domain), ".")] = true
+	}
+	for _, kind := range options.Kinds {
+		extractor.kinds[kind] = true
+	}
+	return extractor, nil
+}
+
+// defaultExtractor is used by Extract.
+var defaultExtractor, _ = NewExtractor(nil)
+
+// Extract finds the indicators of compromise in the text with the DefaultExtractorOptions.
+func Extract(text string) []Indicator {
+	return defaultExtractor.Extract(text)
+}
+
+// match is an indicator along with where it was found.
+type match struct {
+	position  int
+	indicator Indicator
+}
+
+// Extract refangs the text and returns its indicators of compromise, deduplicated, in order of appearance.
+// The domains and IPs that are part of a URL or an email are not extracted on their own.
+func (extractor *Extractor) Extract(text string) []Indicator {
+	text = Refang(text)
+	matches := []match{}
+	// * URLs and emails come first and are masked, so that their hosts are not extracted again
+	text = extractor.find(text, urlPattern, URL, &matches)
+	text = extractor.find(text, emailPattern, Email, &matches)
+	text = extractor.find(text, ipv4Pattern, IP, &matches)
+	text = extractor.find(text, ipv6Pattern, IP, &matches)
+	text = extractor.find(text, hashPattern, Hash, &matches)
+	extractor.find(text, domainPattern, Domain, &matches)
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].position < matches[j].position
+	})
+	indicators := []Indicator{}
+	seen := map[string]bool{}
+	for _, match := range matches {
+		key := string(match.indicator.Kind) + "|" + match.indicator.Value
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		indicators = append(indicators, match.indicator)
+	}
+	return indicators
+}
+
+// find collects the valid indicators of the given kind and returns the text with all of the pattern's matches masked.
+func (extractor *Extractor) find(text string, pattern *regexp.Regexp, kind Kind, matches *[]match) string {
+	locations := pattern.FindAllStringIndex(text, -1)
+	if len(locations) == 0 {
+		return text
+	}
+	masked := []byte(text)
+	for _, location := range locations {
+		value, valid := extractor.normalize(kind, text[location[0]:location[1]])
+		if kind == IP && !isDelimited(text, location[0], location[1]) {
+			valid = false
+		}
+		if valid && extractor.wanted(kind) && !extractor.allowlisted(kind, value) {
+			*matches = append(*matches, match{
+				position: location[0],
+				indicator: Indicator{
+					Kind:           kind,
+					Value:          value,
+					Classification: gointelowl.ClassifyObservable(value),
+				},
+			})
+		}
+		if valid {
+			for index := location[0]; index < location[1]; index++ {
+				masked[index] = ' '
+			}
+		}
+	}
+	return string(masked)
+}
+
+// isDelimited reports whether the match stands on its own, not being part of e.g: a version number "1.2.3.4.5".
+func isDelimited(text string, start int, end int) bool {
+	isPartOfToken := func(character byte) bool {
+		return character == '.' || character == ':' || character == '_' || character == '-' ||
+			('0' <= character && character <= '9') || ('a' <= character && character <= 'z') || ('A' <= character && character <= 'Z')
+	}
+	if start > 0 && isPartOfToken(text[start-1]) {
+		return false
+	}
+	if end >= len(text) || !isPartOfToken(text[end]) {
+		return true
+	}
+	// * an IP can end a sentence and an IPv4 can be followed by a port
+	next := byte(' ')
+	if end+1 < len(text) {
+		next = text[end+1]
+	}
+	switch text[end] {
+	case '.':
+		return !isPartOfToken(next)
+	case ':':
+		return '0' <= next && next <= '9' && !strings.Contains(text[start:end], ":")
+	}
+	return false
+}
+
+// normalize cleans up a match and tells whether it is a valid indicator of the kind.
+func (extractor *Extractor) normalize(kind Kind, value string) (string, bool) {
+	switch kind {
+	case URL:
+		value = strings.TrimRight(value, ".,;:!?")
+		parsedUrl, err := url.Parse(value)
+		return value, err == nil && parsedUrl.Hostname() != ""
+	case Email:
+		return strings.ToLower(value), true
+	case IP:
+		if net.ParseIP(value) == nil {
+			return value, false
+		}
+		if strings.Contains(value, ":") && !strings.HasPrefix(value, "::") {
+			// * single groups such as "e::" are more likely to come from prose or code than from an address
+			groups := 0
+			for _, group := range strings.Split(value, ":") {
+				if group != "" {
+					groups++
+				}
+			}
+			if groups < 2 {
+				return value, false
+			}
+		}
+		return strings.ToLower(value), true
+	case Hash:
+		value = strings.ToLower(value)
+		return value, gointelowl.HashAlgorithm(value) != ""
+	case Domain:
+		value = strings.ToLower(value)
+		topLevelDomain := value[strings.LastIndex(value, ".")+1:]
+		return value, !fileExtensions[topLevelDomain]
+	}
+	return value, false
+}
+
+// wanted reports whether the indicators of the kind are extracted.
+func (extractor *Extractor) wanted(kind Kind) bool {
+	return len(extractor.kinds) == 0 || extractor.kinds[kind]
+}
+
+// allowlisted reports whether the indicator belongs to an allowlisted network or domain.
+func (extractor *Extractor) allowlisted(kind Kind, value string) bool {
+	host := value
+	switch kind {
+	case URL:
+		parsedUrl, err := url.Parse(value)
+		if err != nil {
+			return false
+		}
+		host = parsedUrl.Hostname()
+	case Email:
+		host = value[strings.LastIndex(value, "@")+1:]
+	case Hash:
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range extractor.allowlistedNetworks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	// * checking the domain and every parent domain
+	host = strings.Trim(strings.ToLower(host), ".")
+	for host != "" {
+		if extractor.allowlistedDomains[host] {
+			return true
+		}
+		index := strings.Index(host, ".")
+		if index < 0 {
+			break
+		}
+		host = host[index+1:]
+	}
+	return false
+}
+
+// ObservableAnalysisParams turns indicators into MultipleObservableAnalysisParams
+// ready for gointelowl.IntelOwlClient.CreateMultipleObservableAnalysis.
+func ObservableAnalysisParams(indicators []Indicator, basicAnalysisParams gointelowl.BasicAnalysisParams) *gointelowl.MultipleObservableAnalysisParams {
+	observables := make([][]string, 0, len(indicators))
+	for _, indicator := range indicators {
+		observables = append(observables, []string{indicator.Classification, indicator.Value})
+	}
+	return &gointelowl.MultipleObservableAnalysisParams{
+		BasicAnalysisParams: basicAnalysisParams,
+		Observables:         observables,
+	}
+}
+
+// ExtractObservableAnalysisParams extracts the indicators of the text and turns them into MultipleObservableAnalysisParams.
+func (extractor *Extractor) ExtractObservableAnalysisParams(text string, basicAnalysisParams gointelowl.BasicAnalysisParams) *gointelowl.MultipleObservableAnalysisParams {
+	return ObservableAnalysisParams(extractor.Extract(text), basicAnalysisParams)
+}
@@ -0,0 +1,141 @@
+package ioc
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+// refangRules turn the usual defanging notations back into the original characters.
+var refangRules = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// * hxxp://, hXXps://, fxp://
+	{regexp.MustCompile(`(?i)\bh(?:xx|\*\*)p(s?)\b`), "http$1"},
+	{regexp.MustCompile(`(?i)\bfxp\b`), "ftp"},
+	// * [://], [:]//, [/]
+	{regexp.MustCompile(`\[:\]//|\[://\]`), "://"},
+	{regexp.MustCompile(`\[:\]`), ":"},
+	{regexp.MustCompile(`\[/\]`), "/"},
+	// * [.], (.), {.}, [dot], (dot), {dot}: the whitespace around them is kept so that words of prose are not merged
+	{regexp.MustCompile(`(?i)[\[({]\s?(?:\.|dot)\s?[\])}]`), "."},
+	// * [@], (@), [at], (at)
+	{regexp.MustCompile(`(?i)[\[({]\s?(?:@|at)\s?[\])}]`), "@"},
+}
+
+// Refang turns defanged indicators back into live ones
+// e.g: "hxxps://evil[.]com" becomes "https://evil.com" and "8.8.8(dot)8" becomes "8.8.8.8".
+func Refang(text string) string {
+	for _, rule := range refangRules {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	return text
+}
+
+// defangedSchemes maps the URL schemes to their defanged version.
+var defangedSchemes = map[string]string{
+	"http":  "hxxp",
+	"https": "hxxps",
+	"ftp":   "fxp",
+}
+
+// defangedURLPattern matches the URLs that are already defanged e.g: hxxps://evil[.]com/x.
+var defangedURLPattern = regexp.MustCompile(`(?i)\b(?:hxxps?|fxp)(?:://|\[://\]|\[:\]//)[^\s<>"'\x60{}|\\^()]+`)
+
+// Defang neutralizes the URLs, emails, IPs and domains of the text so that they cannot be clicked or resolved
+// e.g: "https://evil.com/x" becomes "hxxps://evil[.]com/x" and "8.8.8.8" becomes "8[.]8[.]8[.]8".
+// Only the scheme and the host of URLs are defanged, their path and query are left alone.
+// Defanging text that is already defanged leaves it as it is; Refang reverts it.
+func Defang(text string) string {
+	urlLocations := append(urlPattern.FindAllStringIndex(text, -1), defangedURLPattern.FindAllStringIndex(text, -1)...)
+	sort.Slice(urlLocations, func(i, j int) bool {
+		return urlLocations[i][0] < urlLocations[j][0]
+	})
+	var builder strings.Builder
+	last := 0
+	for _, location := range urlLocations {
+		if location[0] < last {
+			continue
+		}
+		builder.WriteString(defangText(text[last:location[0]]))
+		rawUrl := text[location[0]:location[1]]
+		if urlPattern.MatchString(rawUrl) {
+			rawUrl = defangURL(rawUrl)
+		}
+		builder.WriteString(rawUrl)
+		last = location[1]
+	}
+	builder.WriteString(defangText(text[last:]))
+	return builder.String()
+}
+
+// defangText defangs the emails, IPs and domains of text that holds no URL.
+func defangText(text string) string {
+	text = emailPattern.ReplaceAllStringFunc(text, func(email string) string {
+		return defangDots(strings.Replace(email, "@", "[@]", 1))
+	})
+	text = replaceDelimited(text, ipv4Pattern, defangDots)
+	text = replaceDelimited(text, ipv6Pattern, func(ip string) string {
+		return strings.ReplaceAll(ip, ":", "[:]")
+	})
+	return domainPattern.ReplaceAllStringFunc(text, func(domain string) string {
+		if _, valid := defaultExtractor.normalize(Domain, domain); !valid {
+			return domain
+		}
+		return defangDots(domain)
+	})
+}
+
+// DefangJob returns a copy of the job that is safe to share: its observable name and every key and string value of its reports are defanged.
+func DefangJob(job *gointelowl.Job) *gointelowl.Job {
+	return job.Transform(Defang)
+}
+
+// RefangJob returns a copy of the job where its observable name and every key and string value of its reports are refanged.
+func RefangJob(job *gointelowl.Job) *gointelowl.Job {
+	return job.Transform(Refang)
+}
+
+// defangDots replaces the dots with "[.]".
+func defangDots(text string) string {
+	return strings.ReplaceAll(text, ".", "[.]")
+}
+
+// defangURL defangs the scheme and the host of a URL, leaving its path alone.
+func defangURL(rawUrl string) string {
+	trimmedUrl := strings.TrimRight(rawUrl, ".,;:!?")
+	suffix := rawUrl[len(trimmedUrl):]
+	schemeEnd := strings.Index(trimmedUrl, "://")
+	scheme := strings.ToLower(trimmedUrl[:schemeEnd])
+	rest := trimmedUrl[schemeEnd+len("://"):]
+	hostEnd := strings.IndexAny(rest, "/?#")
+	if hostEnd < 0 {
+		hostEnd = len(rest)
+	}
+	host := strings.Replace(defangDots(rest[:hostEnd]), "@", "[@]", 1)
+	return defangedSchemes[scheme] + "://" + host + rest[hostEnd:] + suffix
+}
+
+// replaceDelimited replaces the valid IPs matched by the pattern.
+func replaceDelimited(text string, pattern *regexp.Regexp, replace func(string) string) string {
+	locations := pattern.FindAllStringIndex(text, -1)
+	if len(locations) == 0 {
+		return text
+	}
+	var builder strings.Builder
+	last := 0
+	for _, location := range locations {
+		ip := text[location[0]:location[1]]
+		if _, valid := defaultExtractor.normalize(IP, ip); !valid || !isDelimited(text, location[0], location[1]) {
+			continue
+		}
+		builder.WriteString(text[last:location[0]])
+		builder.WriteString(replace(ip))
+		last = location[1]
+	}
+	builder.WriteString(text[last:])
+	return builder.String()
+}
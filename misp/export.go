@@ -0,0 +1,410 @@
+package misp
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+	"github.com/intelowlproject/go-intelowl/internal/export"
+)
+
+// VerdictMode represents how the verdicts of the analyzers are added to the attributes.
+type VerdictMode int
+
+// Values of the VerdictMode enum.
+const (
+	// VerdictsAsComments writes the verdicts in the comment of the attributes
+	// e.g: IntelOwl job #42: malicious (score 0.90); VirusTotal_v3_Get_Observable: malicious (score 0.90)
+	VerdictsAsComments VerdictMode = iota
+	// VerdictsAsTags adds the verdicts as custom machine tags to the attributes
+	// e.g: intelowl:verdict="malicious" and intelowl:VirusTotal_v3_Get_Observable="malicious".
+	// The intelowl namespace is neither a MISP taxonomy nor a galaxy: MISP stores them as plain tags.
+	VerdictsAsTags
+)
+
+// ExporterOptions represents the fields to configure an Exporter.
+type ExporterOptions struct {
+	VerdictMode VerdictMode
+	// Distribution of the event (default: DistributionOrganisation)
+	Distribution string
+	// Info is the title of the event, made out of the jobs if empty
+	Info string
+	// Summarizer computes the verdicts of the jobs, nil means gointelowl.DefaultSummarizer
+	Summarizer *gointelowl.Summarizer
+	// Now is used for the dates of jobs without a received request time (default: time.Now)
+	Now func() time.Time
+}
+
+// Exporter turns IntelOwl jobs into MISP events.
+type Exporter struct {
+	options ExporterOptions
+}
+
+// NewExporter makes an Exporter, nil options means verdicts as comments in events of your organisation only.
+func NewExporter(options *ExporterOptions) *Exporter {
+	exporter := &Exporter{}
+	if options != nil {
+		exporter.options = *options
+	}
+	if exporter.options.Distribution == "" {
+		exporter.options.Distribution = DistributionOrganisation
+	}
+	if exporter.options.Summarizer == nil {
+		exporter.options.Summarizer = gointelowl.DefaultSummarizer
+	}
+	if exporter.options.Now == nil {
+		exporter.options.Now = time.Now
+	}
+	return exporter
+}
+
+// Export turns jobs into a MISP event with the default Exporter.
+func Export(jobs ...*gointelowl.Job) (*Event, error) {
+	return NewExporter(nil).Export(jobs...)
+}
+
+// Export turns one or more jobs into a single MISP event made of:
+//   - an attribute for every observable, typed after its classification
+//   - a file object, with its hashes, name and mimetype, for every file
+//   - the tags of the jobs and the tlp:* tag of the most restrictive TLP of the jobs
+//
+// The verdicts of the jobs are added to their attributes as set by VerdictMode, and set the threat level
+// of the event. An observable or file found in several jobs is exported once, with the verdicts of every job.
+func (exporter *Exporter) Export(jobs ...*gointelowl.Job) (*Event, error) {
+	if len(jobs) == 0 {
+		return nil, errors.New("No job to export")
+	}
+	eventTlp := gointelowl.WHITE
+	jobIDs := []string{}
+	names := []string{}
+	for _, job := range jobs {
+		tlp := gointelowl.WHITE
+		if job.Tlp != "" {
+			tlp = gointelowl.ParseTLP(job.Tlp)
+		}
+		if !tlp.IsValid() {
+			return nil, fmt.Errorf("Job %d has an unknown TLP %q", job.ID, job.Tlp)
+		}
+		if tlp.IsMoreRestrictiveThan(eventTlp) {
+			eventTlp = tlp
+		}
+		jobIDs = append(jobIDs, fmt.Sprintf("#%d", job.ID))
+		if name := export.ObservableName(job); !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	event := &Event{
+		UUID:          newUUID("event/" + strings.Join(jobIDs, ",")),
+		Info:          exporter.options.Info,
+		ThreatLevelID: ThreatLevelUndefined,
+		Analysis:      AnalysisCompleted,
+		Distribution:  exporter.options.Distribution,
+		Attributes:    []Attribute{},
+		Objects:       []Object{},
+		Tags:          []Tag{{Name: "tlp:" + strings.ToLower(eventTlp.String())}},
+	}
+	if event.Info == "" {
+		if len(jobs) == 1 {
+			event.Info = fmt.Sprintf("IntelOwl job %s: %s", jobIDs[0], names[0])
+		} else {
+			event.Info = fmt.Sprintf("IntelOwl jobs %s: %s", strings.Join(jobIDs, ", "), strings.Join(names, ", "))
+		}
+	}
+
+	var firstTime, lastTime time.Time
+	eventVerdict := gointelowl.VerdictUnknown
+	tagNames := map[string]bool{event.Tags[0].Name: true}
+	attributeIndexes := map[string]int{}
+	objectIndexes := map[string]int{}
+	for _, job := range jobs {
+		createdTime := exporter.options.Now()
+		if job.ReceivedRequestTime != nil {
+			createdTime = *job.ReceivedRequestTime
+		}
+		modifiedTime := createdTime
+		if job.FinishedAnalysisTime != nil && job.FinishedAnalysisTime.After(createdTime) {
+			modifiedTime = *job.FinishedAnalysisTime
+		}
+		if firstTime.IsZero() || createdTime.Before(firstTime) {
+			firstTime = createdTime
+		}
+		if modifiedTime.After(lastTime) {
+			lastTime = modifiedTime
+		}
+		if !job.Status.IsTerminal() {
+			event.Analysis = AnalysisOngoing
+		}
+		for _, tag := range job.Tags {
+			if tag.Label != "" && !tagNames[tag.Label] {
+				tagNames[tag.Label] = true
+				event.Tags = append(event.Tags, Tag{Name: tag.Label, Colour: tag.Color})
+			}
+		}
+
+		jobSummary := exporter.options.Summarizer.Summarize(job)
+		if verdictRanks[jobSummary.Verdict] > verdictRanks[eventVerdict] {
+			eventVerdict = jobSummary.Verdict
+		}
+		comment, verdictTags := exporter.verdicts(job, jobSummary)
+		toIDS := jobSummary.Verdict == gointelowl.VerdictMalicious || jobSummary.Verdict == gointelowl.VerdictSuspicious
+		timestamp := strconv.FormatInt(modifiedTime.Unix(), 10)
+
+		if job.IsSample {
+			object := fileObject(job, event.UUID, timestamp)
+			if index, ok := objectIndexes[object.UUID]; ok {
+				merged := &event.Objects[index]
+				merged.Comment = mergeComments(merged.Comment, comment)
+				for attributeIndex := range merged.Attributes {
+					mergeAttribute(&merged.Attributes[attributeIndex], "", verdictTags, toIDS, timestamp)
+				}
+				merged.Timestamp = laterTimestamp(merged.Timestamp, timestamp)
+				continue
+			}
+			object.Comment = comment
+			for attributeIndex := range object.Attributes {
+				mergeAttribute(&object.Attributes[attributeIndex], "", verdictTags, toIDS, timestamp)
+			}
+			objectIndexes[object.UUID] = len(event.Objects)
+			event.Objects = append(event.Objects, object)
+			continue
+		}
+
+		attributeType, category := attributeTypeOf(job)
+		attribute := Attribute{
+			UUID:         newUUID(event.UUID + "/attribute/" + attributeType + "/" + job.ObservableName),
+			Type:         attributeType,
+			Category:     category,
+			Value:        job.ObservableName,
+			Distribution: DistributionInherit,
+		}
+		if attributeType == "text" {
+			toIDS = false
+		}
+		if index, ok := attributeIndexes[attribute.UUID]; ok {
+			mergeAttribute(&event.Attributes[index], comment, verdictTags, toIDS, timestamp)
+			continue
+		}
+		mergeAttribute(&attribute, comment, verdictTags, toIDS, timestamp)
+		attributeIndexes[attribute.UUID] = len(event.Attributes)
+		event.Attributes = append(event.Attributes, attribute)
+	}
+
+	event.Date = firstTime.UTC().Format("2006-01-02")
+	event.Timestamp = strconv.FormatInt(lastTime.Unix(), 10)
+	event.ThreatLevelID = threatLevels[eventVerdict]
+	if exporter.options.VerdictMode == VerdictsAsTags {
+		event.Tags = append(event.Tags, verdictTag("verdict", eventVerdict))
+	}
+	return event, nil
+}
+
+// verdictRanks orders the verdicts, the event getting the worst verdict of its jobs.
+var verdictRanks = map[gointelowl.Verdict]int{
+	gointelowl.VerdictUnknown:    0,
+	gointelowl.VerdictBenign:     1,
+	gointelowl.VerdictSuspicious: 2,
+	gointelowl.VerdictMalicious:  3,
+}
+
+// threatLevels maps the verdicts to the threat levels of the event.
+var threatLevels = map[gointelowl.Verdict]string{
+	gointelowl.VerdictUnknown:    ThreatLevelUndefined,
+	gointelowl.VerdictBenign:     ThreatLevelLow,
+	gointelowl.VerdictSuspicious: ThreatLevelMedium,
+	gointelowl.VerdictMalicious:  ThreatLevelHigh,
+}
+
+// hashTypes maps the hash algorithms to the MISP attribute types.
+var hashTypes = map[string]string{
+	gointelowl.MD5Hash:    "md5",
+	gointelowl.SHA1Hash:   "sha1",
+	gointelowl.SHA256Hash: "sha256",
+	gointelowl.SHA512Hash: "sha512",
+}
+
+// attributeTypeOf returns the MISP type and category of the attribute of an observable.
+func attributeTypeOf(job *gointelowl.Job) (string, string) {
+	value := job.ObservableName
+	classification := job.ObservableClassification
+	if classification == "" {
+		classification = gointelowl.ClassifyObservable(value)
+	}
+	switch classification {
+	case gointelowl.IPClassification:
+		return "ip-dst", "Network activity"
+	case gointelowl.DomainClassification:
+		return "domain", "Network activity"
+	case gointelowl.URLClassification:
+		return "url", "Network activity"
+	case gointelowl.HashClassification:
+		if hashType, ok := hashTypes[gointelowl.HashAlgorithm(value)]; ok {
+			return hashType, "Payload delivery"
+		}
+	case gointelowl.GenericClassification:
+		if address, err := mail.ParseAddress(value); err == nil && address.Address == value {
+			return "email", "Network activity"
+		}
+	}
+	return "text", "Other"
+}
+
+// fileObject makes the file object of a sample: its hashes, name and mimetype, completed with the File_Info report.
+func fileObject(job *gointelowl.Job, eventUUID string, timestamp string) Object {
+	fileInfoReport := gointelowl.FileInfoReport{}
+	for _, report := range job.AnalyzerReports {
+		if report.Name == "File_Info" && report.Status.IsSuccessful() {
+			_ = report.Decode(&fileInfoReport)
+		}
+	}
+	md5 := job.Md5
+	if md5 == "" {
+		md5 = fileInfoReport.Md5
+	}
+	mimetype := job.FileMimetype
+	if mimetype == "" {
+		mimetype = fileInfoReport.Mimetype
+	}
+	object := Object{
+		UUID:            newUUID(eventUUID + "/object/file/" + fileKey(job, md5, fileInfoReport.Sha256)),
+		Name:            "file",
+		MetaCategory:    "file",
+		Description:     "File object describing a file with meta-information",
+		TemplateUUID:    FileObjectTemplateUUID,
+		TemplateVersion: "24",
+		Distribution:    DistributionInherit,
+		Timestamp:       timestamp,
+		Attributes:      []Attribute{},
+	}
+	for _, field := range []struct {
+		relation string
+		category string
+		value    string
+	}{
+		{"md5", "Payload delivery", strings.ToLower(md5)},
+		{"sha1", "Payload delivery", strings.ToLower(fileInfoReport.Sha1)},
+		{"sha256", "Payload delivery", strings.ToLower(fileInfoReport.Sha256)},
+		{"filename", "Payload delivery", job.FileName},
+		{"mimetype", "Artifacts dropped", mimetype},
+	} {
+		if field.value == "" {
+			continue
+		}
+		attributeType := field.relation
+		if attributeType == "mimetype" {
+			attributeType = "mime-type"
+		}
+		object.Attributes = append(object.Attributes, Attribute{
+			UUID:           newUUID(object.UUID + "/" + field.relation),
+			Type:           attributeType,
+			Category:       field.category,
+			Value:          field.value,
+			Distribution:   DistributionInherit,
+			ObjectRelation: field.relation,
+		})
+	}
+	return object
+}
+
+// fileKey is what identifies the file of a sample in its event: its MD5, else its SHA256, its name
+// or, without any of them, the job itself.
+func fileKey(job *gointelowl.Job, md5 string, sha256 string) string {
+	switch {
+	case md5 != "":
+		return strings.ToLower(md5)
+	case sha256 != "":
+		return "sha256/" + strings.ToLower(sha256)
+	case job.FileName != "":
+		return "filename/" + job.FileName
+	default:
+		return fmt.Sprintf("job/%d", job.ID)
+	}
+}
+
+// verdicts returns the comment and the tags telling the verdicts of a job.
+func (exporter *Exporter) verdicts(job *gointelowl.Job, jobSummary *gointelowl.JobSummary) (string, []Tag) {
+	comment := fmt.Sprintf("IntelOwl job #%d", job.ID)
+	if exporter.options.VerdictMode == VerdictsAsTags {
+		tags := []Tag{verdictTag("verdict", jobSummary.Verdict)}
+		for _, analyzerVerdict := range jobSummary.Verdicts {
+			if analyzerVerdict.Reason == "" {
+				tags = append(tags, verdictTag(analyzerVerdict.Analyzer, analyzerVerdict.Verdict))
+			}
+		}
+		return comment, tags
+	}
+	verdicts := []string{fmt.Sprintf("%s: %s (score %.2f)", comment, jobSummary.Verdict, jobSummary.Score)}
+	for _, analyzerVerdict := range jobSummary.Verdicts {
+		if analyzerVerdict.Reason == "" {
+			verdicts = append(verdicts, fmt.Sprintf("%s: %s (score %.2f)", analyzerVerdict.Analyzer, analyzerVerdict.Verdict, analyzerVerdict.Score))
+		}
+	}
+	return strings.Join(verdicts, "; "), nil
+}
+
+// verdictTag makes the custom machine tag of a verdict e.g: intelowl:verdict="malicious".
+func verdictTag(predicate string, verdict gointelowl.Verdict) Tag {
+	return Tag{Name: fmt.Sprintf("intelowl:%s=%q", predicate, verdict)}
+}
+
+// mergeAttribute adds the verdicts of a job to an attribute.
+func mergeAttribute(attribute *Attribute, comment string, tags []Tag, toIDS bool, timestamp string) {
+	// * in a file object, the comment goes to the object and the verdicts to the hashes only
+	if attribute.ObjectRelation == "" {
+		attribute.Comment = mergeComments(attribute.Comment, comment)
+	}
+	if attribute.ObjectRelation == "" || hashTypes[attribute.ObjectRelation] != "" {
+		attribute.ToIDS = attribute.ToIDS || toIDS
+		for _, tag := range tags {
+			if !hasTag(attribute.Tags, tag.Name) {
+				attribute.Tags = append(attribute.Tags, tag)
+			}
+		}
+	}
+	attribute.Timestamp = laterTimestamp(attribute.Timestamp, timestamp)
+}
+
+// laterTimestamp returns the later of two MISP timestamps, in seconds since the epoch.
+func laterTimestamp(timestamp string, other string) string {
+	seconds, _ := strconv.ParseInt(timestamp, 10, 64)
+	otherSeconds, _ := strconv.ParseInt(other, 10, 64)
+	if otherSeconds > seconds {
+		return other
+	}
+	return timestamp
+}
+
+// mergeComments joins the comments of the jobs of an attribute.
+func mergeComments(comment string, other string) string {
+	if comment == "" || comment == other {
+		return other
+	}
+	if other == "" {
+		return comment
+	}
+	return comment + "\n" + other
+}
+
+// containsString reports whether the string is in the list.
+func containsString(values []string, value string) bool {
+	for _, other := range values {
+		if other == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag reports whether a tag with the given name is in the list.
+func hasTag(tags []Tag, name string) bool {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+// Package misp exports IntelOwl jobs as MISP events, ready to be pushed to a MISP instance.
+//
+// MISP core format: https://www.misp-project.org/datamodels/
+package misp
+
+import (
+	"encoding/json"
+
+	"github.com/intelowlproject/go-intelowl/internal/export"
+)
+
+// Values of the MISP threat levels.
+const (
+	ThreatLevelHigh      = "1"
+	ThreatLevelMedium    = "2"
+	ThreatLevelLow       = "3"
+	ThreatLevelUndefined = "4"
+)
+
+// Values of the MISP analysis levels.
+const (
+	AnalysisInitial   = "0"
+	AnalysisOngoing   = "1"
+	AnalysisCompleted = "2"
+)
+
+// Values of the MISP distributions.
+const (
+	DistributionOrganisation = "0"
+	DistributionCommunity    = "1"
+	DistributionConnected    = "2"
+	DistributionAll          = "3"
+	DistributionSharingGroup = "4"
+	// DistributionInherit gives attributes and objects the distribution of their event
+	DistributionInherit = "5"
+)
+
+// FileObjectTemplateUUID is the UUID of the MISP file object template.
+const FileObjectTemplateUUID = "688c46fb-5edb-40a3-8273-1af7923e2215"
+
+// Tag represents a MISP tag e.g: the tlp:amber taxonomy tag or the intelowl:verdict="malicious" custom machine tag.
+type Tag struct {
+	Name   string `json:"name"`
+	Colour string `json:"colour,omitempty"`
+}
+
+// Attribute represents a MISP attribute, on its own or in an object.
+type Attribute struct {
+	UUID               string `json:"uuid"`
+	Type               string `json:"type"`
+	Category           string `json:"category"`
+	Value              string `json:"value"`
+	ToIDS              bool   `json:"to_ids"`
+	Comment            string `json:"comment"`
+	Distribution       string `json:"distribution"`
+	Timestamp          string `json:"timestamp"`
+	DisableCorrelation bool   `json:"disable_correlation"`
+	ObjectRelation     string `json:"object_relation,omitempty"`
+	Tags               []Tag  `json:"Tag,omitempty"`
+}
+
+// Object represents a MISP object, a group of attributes following a template e.g: a file.
+type Object struct {
+	UUID            string      `json:"uuid"`
+	Name            string      `json:"name"`
+	MetaCategory    string      `json:"meta-category"`
+	Description     string      `json:"description"`
+	TemplateUUID    string      `json:"template_uuid"`
+	TemplateVersion string      `json:"template_version"`
+	Comment         string      `json:"comment"`
+	Distribution    string      `json:"distribution"`
+	Timestamp       string      `json:"timestamp"`
+	Attributes      []Attribute `json:"Attribute"`
+}
+
+// Event represents a MISP event.
+type Event struct {
+	UUID          string      `json:"uuid"`
+	Info          string      `json:"info"`
+	Date          string      `json:"date"`
+	ThreatLevelID string      `json:"threat_level_id"`
+	Analysis      string      `json:"analysis"`
+	Distribution  string      `json:"distribution"`
+	Published     bool        `json:"published"`
+	Timestamp     string      `json:"timestamp"`
+	Attributes    []Attribute `json:"Attribute"`
+	Objects       []Object    `json:"Object"`
+	Tags          []Tag       `json:"Tag"`
+}
+
+// EventDocument represents the JSON document MISP reads and writes, the event wrapped in an Event key.
+type EventDocument struct {
+	Event *Event `json:"Event"`
+}
+
+// JSON returns the event as the JSON document the MISP API accepts e.g: on POST /events/add.
+func (event *Event) JSON() ([]byte, error) {
+	return json.Marshal(EventDocument{Event: event})
+}
+
+// AttributesOfType returns the attributes of the event, including the ones of its objects, of the given type.
+func (event *Event) AttributesOfType(attributeType string) []Attribute {
+	attributes := []Attribute{}
+	for _, attribute := range event.Attributes {
+		if attribute.Type == attributeType {
+			attributes = append(attributes, attribute)
+		}
+	}
+	for _, object := range event.Objects {
+		for _, attribute := range object.Attributes {
+			if attribute.Type == attributeType {
+				attributes = append(attributes, attribute)
+			}
+		}
+	}
+	return attributes
+}
+
+// namespace is the namespace of the identifiers of the exported events, attributes and objects, so that
+// exporting a job twice gives the same identifiers.
+var namespace = export.UUIDv5(export.URLNamespace, "https://github.com/intelowlproject/go-intelowl/misp")
+
+// newUUID makes the identifier of an exported event, attribute or object out of what makes it unique.
+func newUUID(name string) string {
+	return export.FormatUUID(export.UUIDv5(namespace, name))
+}
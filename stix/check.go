@@ -0,0 +1,254 @@
+package stix
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PropertiesError is returned when a bundle lacks required properties or has malformed ones.
+type PropertiesError struct {
+	Problems []string
+}
+
+// Error lets you implement the error interface.
+func (propertiesError *PropertiesError) Error() string {
+	return fmt.Sprintf("Invalid STIX bundle properties: %s", strings.Join(propertiesError.Problems, "; "))
+}
+
+var (
+	typePattern             = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,248}[a-z0-9]$`)
+	identifierPattern       = regexp.MustCompile(`^([a-z0-9][a-z0-9-]{1,248}[a-z0-9])--[0-9a-f]{8}-[0-9a-f]{4}-[1-8][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	timestampPattern        = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?Z$`)
+	propertyNamePattern     = regexp.MustCompile(`^[a-z][a-z0-9_]{0,249}$`)
+	customPropertyPattern   = regexp.MustCompile(`^x_[a-z0-9_]{1,248}$`)
+	relationshipTypePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+)
+
+// cyberObservableTypes are the STIX Cyber-observable Objects, which have no created and modified properties.
+var cyberObservableTypes = map[string]bool{
+	"artifact": true, "autonomous-system": true, "directory": true, "domain-name": true, "email-addr": true,
+	"email-message": true, "file": true, "ipv4-addr": true, "ipv6-addr": true, "mac-addr": true, "mutex": true,
+	"network-traffic": true, "process": true, "software": true, "url": true, "user-account": true,
+	"windows-registry-key": true, "x509-certificate": true,
+}
+
+// requiredProperties are the properties each object type must have, besides the common ones.
+var requiredProperties = map[string][]string{
+	"identity":           {"name"},
+	"indicator":          {"pattern", "pattern_type", "valid_from"},
+	"infrastructure":     {"name"},
+	"malware":            {"is_family"},
+	"marking-definition": {"definition_type", "definition"},
+	"note":               {"content", "object_refs"},
+	"observed-data":      {"first_observed", "last_observed", "number_observed", "object_refs"},
+	"relationship":       {"relationship_type", "source_ref", "target_ref"},
+	"report":             {"name", "published", "object_refs"},
+	"domain-name":        {"value"},
+	"email-addr":         {"value"},
+	"ipv4-addr":          {"value"},
+	"ipv6-addr":          {"value"},
+	"url":                {"value"},
+}
+
+// CheckRequiredProperties checks the properties of a STIX 2.1 bundle, as JSON: the identifiers, the timestamps,
+// the property names, the common properties and the required properties of the object types the exporter makes.
+// It is a sanity check, not a validation against the STIX 2.1 JSON schemas: objects of other types only get the
+// common checks and the values are not checked against the vocabularies or the patterning grammar.
+// The returned error is a *PropertiesError listing every problem.
+func CheckRequiredProperties(data []byte) error {
+	bundle := map[string]interface{}{}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return &PropertiesError{Problems: []string{fmt.Sprintf("the bundle is not a JSON object: %s", err)}}
+	}
+	checker := &checker{}
+	if bundle["type"] != "bundle" {
+		checker.problem("the type of the bundle is %v, not bundle", bundle["type"])
+	}
+	checker.checkIdentifier("the bundle", "id", bundle["id"], "bundle")
+	if objects, ok := bundle["objects"]; ok {
+		objectList, ok := objects.([]interface{})
+		if !ok || len(objectList) == 0 {
+			checker.problem("the objects of the bundle must be a non-empty list")
+		}
+		for index, object := range objectList {
+			objectProperties, ok := object.(map[string]interface{})
+			if !ok {
+				checker.problem("the object %d is not a JSON object", index)
+				continue
+			}
+			checker.checkObject(index, objectProperties)
+		}
+	}
+	if len(checker.problems) > 0 {
+		return &PropertiesError{Problems: checker.problems}
+	}
+	return nil
+}
+
+// checker collects the problems of a bundle.
+type checker struct {
+	problems []string
+}
+
+// problem adds a problem of the bundle.
+func (checker *checker) problem(format string, values ...interface{}) {
+	checker.problems = append(checker.problems, fmt.Sprintf(format, values...))
+}
+
+// checkObject checks the common and the required properties of an object.
+func (checker *checker) checkObject(index int, object map[string]interface{}) {
+	objectType, _ := object["type"].(string)
+	name := fmt.Sprintf("the object %d", index)
+	if id, ok := object["id"].(string); ok {
+		name = id
+	}
+	if !typePattern.MatchString(objectType) {
+		checker.problem("%s has an invalid type %v", name, object["type"])
+		return
+	}
+	checker.checkIdentifier(name, "id", object["id"], objectType)
+
+	propertyNames := make([]string, 0, len(object))
+	for propertyName := range object {
+		propertyNames = append(propertyNames, propertyName)
+	}
+	sort.Strings(propertyNames)
+	for _, propertyName := range propertyNames {
+		value := object[propertyName]
+		if !propertyNamePattern.MatchString(propertyName) {
+			checker.problem("%s has an invalid property name %q", name, propertyName)
+		}
+		if strings.HasPrefix(propertyName, "x_") && !customPropertyPattern.MatchString(propertyName) {
+			checker.problem("%s has an invalid custom property name %q", name, propertyName)
+		}
+		if strings.HasSuffix(propertyName, "_ref") {
+			checker.checkIdentifier(name, propertyName, value, "")
+		}
+		if strings.HasSuffix(propertyName, "_refs") {
+			references, ok := value.([]interface{})
+			if !ok || len(references) == 0 {
+				checker.problem("%s: %s must be a non-empty list", name, propertyName)
+			}
+			for _, reference := range references {
+				checker.checkIdentifier(name, propertyName, reference, "")
+			}
+		}
+	}
+
+	isCyberObservable := cyberObservableTypes[objectType]
+	if specVersion, ok := object["spec_version"]; ok || !isCyberObservable {
+		if specVersion != SpecVersion {
+			checker.problem("%s: spec_version must be %s, got %v", name, SpecVersion, specVersion)
+		}
+	}
+	if !isCyberObservable {
+		checker.checkTimestamp(name, "created", object["created"])
+		if objectType != "marking-definition" {
+			modified := checker.checkTimestamp(name, "modified", object["modified"])
+			created, _ := time.Parse(time.RFC3339Nano, fmt.Sprint(object["created"]))
+			if !modified.IsZero() && modified.Before(created) {
+				checker.problem("%s: modified must not be before created", name)
+			}
+		}
+	}
+	for _, propertyName := range requiredProperties[objectType] {
+		if value, ok := object[propertyName]; !ok || value == nil || value == "" {
+			checker.problem("%s: the %s property is required", name, propertyName)
+		}
+	}
+	if labels, ok := object["labels"]; ok {
+		checker.checkStrings(name, "labels", labels)
+	}
+
+	switch objectType {
+	case "indicator":
+		checker.checkTimestamp(name, "valid_from", object["valid_from"])
+		if pattern, _ := object["pattern"].(string); object["pattern_type"] == "stix" && !(strings.HasPrefix(pattern, "[") && strings.HasSuffix(pattern, "]")) {
+			checker.problem("%s: the STIX pattern %q must be between brackets", name, pattern)
+		}
+		if types, ok := object["indicator_types"]; ok {
+			checker.checkStrings(name, "indicator_types", types)
+		}
+	case "malware":
+		isFamily, ok := object["is_family"].(bool)
+		if !ok {
+			checker.problem("%s: is_family must be a boolean", name)
+		} else if isFamily && object["name"] == nil {
+			checker.problem("%s: the name property is required for a malware family", name)
+		}
+	case "observed-data":
+		first := checker.checkTimestamp(name, "first_observed", object["first_observed"])
+		last := checker.checkTimestamp(name, "last_observed", object["last_observed"])
+		if !first.IsZero() && !last.IsZero() && last.Before(first) {
+			checker.problem("%s: last_observed must not be before first_observed", name)
+		}
+		if numberObserved, ok := object["number_observed"].(float64); !ok || numberObserved < 1 || numberObserved > 999999999 || numberObserved != float64(int64(numberObserved)) {
+			checker.problem("%s: number_observed must be an integer between 1 and 999999999", name)
+		}
+	case "relationship":
+		if relationshipType, _ := object["relationship_type"].(string); !relationshipTypePattern.MatchString(relationshipType) {
+			checker.problem("%s: invalid relationship_type %v", name, object["relationship_type"])
+		}
+	case "report":
+		checker.checkTimestamp(name, "published", object["published"])
+	case "file":
+		hashes, hasHashes := object["hashes"]
+		if !hasHashes && object["name"] == nil {
+			checker.problem("%s: a file must have hashes or a name", name)
+		}
+		if hasHashes {
+			hashMap, ok := hashes.(map[string]interface{})
+			if !ok || len(hashMap) == 0 {
+				checker.problem("%s: hashes must be a non-empty dictionary", name)
+			}
+			for algorithm, hash := range hashMap {
+				if hashValue, ok := hash.(string); !ok || hashValue == "" {
+					checker.problem("%s: the %s hash must be a non-empty string", name, algorithm)
+				}
+			}
+		}
+	}
+}
+
+// checkIdentifier checks an identifier, of the given type unless it is empty.
+func (checker *checker) checkIdentifier(name string, propertyName string, value interface{}, objectType string) {
+	id, _ := value.(string)
+	match := identifierPattern.FindStringSubmatch(id)
+	if match == nil {
+		checker.problem("%s: %s must be an identifier, got %v", name, propertyName, value)
+		return
+	}
+	if objectType != "" && match[1] != objectType {
+		checker.problem("%s: %s must be an identifier of type %s", name, propertyName, objectType)
+	}
+}
+
+// checkTimestamp checks a timestamp, returning it parsed.
+func (checker *checker) checkTimestamp(name string, propertyName string, value interface{}) time.Time {
+	timestampString, _ := value.(string)
+	timestamp, err := time.Parse(time.RFC3339Nano, timestampString)
+	if !timestampPattern.MatchString(timestampString) || err != nil {
+		checker.problem("%s: %s must be a UTC timestamp, got %v", name, propertyName, value)
+		return time.Time{}
+	}
+	return timestamp
+}
+
+// checkStrings checks a list of strings.
+func (checker *checker) checkStrings(name string, propertyName string, value interface{}) {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		checker.problem("%s: %s must be a non-empty list of strings", name, propertyName)
+		return
+	}
+	for _, item := range list {
+		if text, ok := item.(string); !ok || text == "" {
+			checker.problem("%s: %s must be a non-empty list of strings", name, propertyName)
+			return
+		}
+	}
+}
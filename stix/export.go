@@ -0,0 +1,427 @@
+package stix
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+	"github.com/intelowlproject/go-intelowl/internal/export"
+)
+
+// ObservableMode represents how the analyzed observable or file is exported.
+type ObservableMode int
+
+// Values of the ObservableMode enum.
+const (
+	// AsIndicator exports an indicator with a STIX pattern matching the observable or file hash
+	AsIndicator ObservableMode = iota
+	// AsObservedData exports an observed-data referencing a STIX Cyber-observable Object
+	AsObservedData
+)
+
+// ExporterOptions represents the fields to configure an Exporter.
+type ExporterOptions struct {
+	Mode ObservableMode
+	// IdentityName is the name of the identity creating the objects (default: IntelOwl)
+	IdentityName string
+	// Summarizer computes the verdict of the job, nil means gointelowl.DefaultSummarizer
+	Summarizer *gointelowl.Summarizer
+	// Now is used for the timestamps of jobs without a received request time (default: time.Now)
+	Now func() time.Time
+}
+
+// Hook lets the report of an analyzer contribute extra objects to the bundle, such as malware or infrastructure.
+// The objects it returns are referenced by the report object, except for the relationships.
+type Hook func(hookContext *HookContext) ([]Object, error)
+
+// HookContext is what a Hook knows about the job being exported.
+type HookContext struct {
+	Job    *gointelowl.Job
+	Report *gointelowl.Report
+	// ObservableRef is the id of the indicator or observed-data of the job, empty if the observable cannot be exported
+	ObservableRef string
+	created       string
+	modified      string
+	identityRef   string
+	markingRef    string
+}
+
+// NewObject makes an object of the given type with the common properties filled in. Its id is derived from
+// the job, the type and the name, so that exporting the same job twice gives the same object.
+func (hookContext *HookContext) NewObject(objectType string, name string) Object {
+	return Object{
+		"type":                objectType,
+		"spec_version":        SpecVersion,
+		"id":                  objectID(objectType, fmt.Sprintf("%d/%s", hookContext.Job.ID, name)),
+		"created":             hookContext.created,
+		"modified":            hookContext.modified,
+		"created_by_ref":      hookContext.identityRef,
+		"object_marking_refs": []string{hookContext.markingRef},
+	}
+}
+
+// Relationship makes a relationship object e.g: Relationship(indicatorRef, "indicates", malwareRef).
+func (hookContext *HookContext) Relationship(sourceRef string, relationshipType string, targetRef string) Object {
+	relationship := hookContext.NewObject("relationship", sourceRef+"/"+relationshipType+"/"+targetRef)
+	relationship["relationship_type"] = relationshipType
+	relationship["source_ref"] = sourceRef
+	relationship["target_ref"] = targetRef
+	return relationship
+}
+
+// Exporter turns IntelOwl jobs into STIX 2.1 bundles. It is safe for concurrent use.
+type Exporter struct {
+	options ExporterOptions
+	mutex   sync.RWMutex
+	hooks   map[string]Hook
+}
+
+// NewExporter makes an Exporter, nil options means indicators created by IntelOwl.
+// The Yara analyzer comes with YaraMalwareHook.
+func NewExporter(options *ExporterOptions) *Exporter {
+	exporter := &Exporter{
+		hooks: map[string]Hook{},
+	}
+	if options != nil {
+		exporter.options = *options
+	}
+	if exporter.options.IdentityName == "" {
+		exporter.options.IdentityName = "IntelOwl"
+	}
+	if exporter.options.Summarizer == nil {
+		exporter.options.Summarizer = gointelowl.DefaultSummarizer
+	}
+	if exporter.options.Now == nil {
+		exporter.options.Now = time.Now
+	}
+	exporter.RegisterHook("Yara", YaraMalwareHook)
+	return exporter
+}
+
+// RegisterHook registers the hook of an analyzer's reports, replacing the one registered before.
+func (exporter *Exporter) RegisterHook(analyzerName string, hook Hook) {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.hooks[analyzerName] = hook
+}
+
+// Export turns a job into a STIX bundle with the default Exporter.
+func Export(job *gointelowl.Job) (*Bundle, error) {
+	return NewExporter(nil).Export(job)
+}
+
+// Export turns a job into a STIX bundle made of:
+//   - the identity creating the objects and the TLP marking definition of the job, marking every object
+//   - an indicator or an observed-data for the observable or file, labeled with the job's tags
+//   - a note for every analyzer report that has stopped running
+//   - the objects contributed by the hooks
+//   - a report referencing all of them
+func (exporter *Exporter) Export(job *gointelowl.Job) (*Bundle, error) {
+	exporter.mutex.RLock()
+	defer exporter.mutex.RUnlock()
+
+	tlp := gointelowl.WHITE
+	if job.Tlp != "" {
+		tlp = gointelowl.ParseTLP(job.Tlp)
+	}
+	markingDefinition, err := TLPMarkingDefinition(tlp)
+	if err != nil {
+		return nil, fmt.Errorf("Job %d has an unknown TLP %q", job.ID, job.Tlp)
+	}
+	createdTime := exporter.options.Now()
+	if job.ReceivedRequestTime != nil {
+		createdTime = *job.ReceivedRequestTime
+	}
+	modifiedTime := createdTime
+	if job.FinishedAnalysisTime != nil && job.FinishedAnalysisTime.After(createdTime) {
+		modifiedTime = *job.FinishedAnalysisTime
+	}
+	identity := Object{
+		"type":           "identity",
+		"spec_version":   SpecVersion,
+		"id":             objectID("identity", exporter.options.IdentityName),
+		"created":        identityTimestamp,
+		"modified":       identityTimestamp,
+		"name":           exporter.options.IdentityName,
+		"identity_class": "system",
+	}
+	hookContext := &HookContext{
+		Job:         job,
+		created:     formatTimestamp(createdTime),
+		modified:    formatTimestamp(modifiedTime),
+		identityRef: identity.ID(),
+		markingRef:  markingDefinition.ID(),
+	}
+	labels := []string{}
+	for _, tag := range job.Tags {
+		if tag.Label != "" {
+			labels = append(labels, tag.Label)
+		}
+	}
+	jobSummary := exporter.options.Summarizer.Summarize(job)
+
+	bundle := &Bundle{
+		Type:    "bundle",
+		ID:      objectID("bundle", strconv.Itoa(job.ID)),
+		Objects: []Object{identity, markingDefinition},
+	}
+	referenced := []Object{}
+	if cyberObservable, pattern := cyberObservable(job); cyberObservable != nil {
+		var observableObject Object
+		if exporter.options.Mode == AsObservedData {
+			observableObject = hookContext.NewObject("observed-data", "observed-data")
+			observableObject["first_observed"] = hookContext.created
+			observableObject["last_observed"] = hookContext.modified
+			observableObject["number_observed"] = 1
+			observableObject["object_refs"] = []string{cyberObservable.ID()}
+			cyberObservable["object_marking_refs"] = []string{hookContext.markingRef}
+			referenced = append(referenced, cyberObservable)
+		} else {
+			observableObject = hookContext.NewObject("indicator", "indicator")
+			observableObject["name"] = export.ObservableName(job)
+			observableObject["pattern"] = pattern
+			observableObject["pattern_type"] = "stix"
+			observableObject["valid_from"] = hookContext.created
+			observableObject["indicator_types"] = []string{indicatorTypes[jobSummary.Verdict]}
+		}
+		if len(labels) > 0 {
+			observableObject["labels"] = labels
+		}
+		observableObject["x_intelowl_verdict"] = string(jobSummary.Verdict)
+		observableObject["x_intelowl_score"] = jobSummary.Score
+		hookContext.ObservableRef = observableObject.ID()
+		referenced = append(referenced, observableObject)
+	}
+
+	reportObject := hookContext.NewObject("report", "report")
+	hookObjects := []Object{}
+	for index := range job.AnalyzerReports {
+		analyzerReport := &job.AnalyzerReports[index]
+		if !analyzerReport.Status.IsTerminal() {
+			continue
+		}
+		note := hookContext.NewObject("note", "note/"+analyzerReport.Name)
+		note["abstract"] = fmt.Sprintf("%s: %s", analyzerReport.Name, analyzerReport.Status)
+		note["content"] = noteContent(analyzerReport)
+		note["authors"] = []string{analyzerReport.Name}
+		if hookContext.ObservableRef != "" {
+			note["object_refs"] = []string{hookContext.ObservableRef}
+		} else {
+			note["object_refs"] = []string{reportObject.ID()}
+		}
+		referenced = append(referenced, note)
+
+		hook, ok := exporter.hooks[analyzerReport.Name]
+		if !ok || !analyzerReport.Status.IsSuccessful() {
+			continue
+		}
+		hookContext.Report = analyzerReport
+		objects, err := hook(hookContext)
+		if err != nil {
+			return nil, fmt.Errorf("Could not export the report of %s: %w", analyzerReport.Name, err)
+		}
+		hookObjects = append(hookObjects, objects...)
+	}
+	hookContext.Report = nil
+	for _, object := range hookObjects {
+		if object.Type() == "relationship" {
+			bundle.Objects = append(bundle.Objects, object)
+		} else {
+			referenced = append(referenced, object)
+		}
+	}
+
+	objectRefs := []string{}
+	for _, object := range referenced {
+		objectRefs = append(objectRefs, object.ID())
+	}
+	reportObject["name"] = fmt.Sprintf("IntelOwl job #%d: %s", job.ID, export.ObservableName(job))
+	reportObject["description"] = fmt.Sprintf("Analyzed by %d analyzers, verdict: %s", len(jobSummary.Verdicts)+len(jobSummary.Failed), jobSummary.Verdict)
+	reportObject["published"] = hookContext.modified
+	reportObject["report_types"] = []string{"threat-report"}
+	reportObject["object_refs"] = objectRefs
+	if len(labels) > 0 {
+		reportObject["labels"] = labels
+	}
+	reportObject["x_intelowl_job_id"] = job.ID
+	bundle.Objects = append(bundle.Objects, referenced...)
+	bundle.Objects = append(bundle.Objects, reportObject)
+	sortRelationshipsLast(bundle.Objects)
+	return bundle, nil
+}
+
+// identityTimestamp is when the identity was created: the identity being the same in every bundle, so are its timestamps.
+const identityTimestamp = "2020-01-01T00:00:00.000Z"
+
+// indicatorTypes maps the verdicts to the indicator types of the STIX vocabulary.
+var indicatorTypes = map[gointelowl.Verdict]string{
+	gointelowl.VerdictMalicious:  "malicious-activity",
+	gointelowl.VerdictSuspicious: "anomalous-activity",
+	gointelowl.VerdictBenign:     "benign",
+	gointelowl.VerdictUnknown:    "unknown",
+}
+
+// hashNames maps the hash algorithms to the names of the STIX vocabulary, in order of preference for the identifiers.
+var hashNames = []struct {
+	algorithm string
+	name      string
+}{
+	{gointelowl.MD5Hash, "MD5"},
+	{gointelowl.SHA1Hash, "SHA-1"},
+	{gointelowl.SHA256Hash, "SHA-256"},
+	{gointelowl.SHA512Hash, "SHA-512"},
+}
+
+// cyberObservable makes the STIX Cyber-observable Object of the observable or file along with a pattern matching it.
+// It returns nil if the observable has no STIX counterpart e.g: a generic observable that is not an email.
+func cyberObservable(job *gointelowl.Job) (Object, string) {
+	if job.IsSample {
+		return fileObservable(job, map[string]string{"MD5": job.Md5})
+	}
+	value := job.ObservableName
+	classification := job.ObservableClassification
+	if classification == "" {
+		classification = gointelowl.ClassifyObservable(value)
+	}
+	objectType := ""
+	switch classification {
+	case gointelowl.IPClassification:
+		if ip := net.ParseIP(value); ip != nil && ip.To4() == nil {
+			objectType = "ipv6-addr"
+		} else {
+			objectType = "ipv4-addr"
+		}
+	case gointelowl.DomainClassification:
+		objectType = "domain-name"
+	case gointelowl.URLClassification:
+		objectType = "url"
+	case gointelowl.HashClassification:
+		algorithm := gointelowl.HashAlgorithm(value)
+		for _, hashName := range hashNames {
+			if hashName.algorithm == algorithm {
+				return fileObservable(job, map[string]string{hashName.name: strings.ToLower(value)})
+			}
+		}
+		return nil, ""
+	case gointelowl.GenericClassification:
+		if address, err := mail.ParseAddress(value); err == nil && address.Address == value {
+			objectType = "email-addr"
+		}
+	}
+	if objectType == "" {
+		return nil, ""
+	}
+	return Object{
+		"type":         objectType,
+		"spec_version": SpecVersion,
+		"id":           scoID(objectType, map[string]interface{}{"value": value}),
+		"value":        value,
+	}, fmt.Sprintf("[%s:value = '%s']", objectType, escapePattern(value))
+}
+
+// fileObservable makes a file with the given hashes, adding the file name and mimetype of samples.
+func fileObservable(job *gointelowl.Job, hashes map[string]string) (Object, string) {
+	for _, report := range job.AnalyzerReports {
+		fileInfoReport := gointelowl.FileInfoReport{}
+		if report.Name != "File_Info" || report.Decode(&fileInfoReport) != nil {
+			continue
+		}
+		for name, hash := range map[string]string{"MD5": fileInfoReport.Md5, "SHA-1": fileInfoReport.Sha1, "SHA-256": fileInfoReport.Sha256} {
+			if hash != "" {
+				hashes[name] = hash
+			}
+		}
+	}
+	file := Object{
+		"type":         "file",
+		"spec_version": SpecVersion,
+		"hashes":       hashes,
+	}
+	contributingProperties := map[string]interface{}{}
+	for _, hashName := range hashNames {
+		if hash, ok := hashes[hashName.name]; ok && hash != "" {
+			contributingProperties["hashes"] = map[string]string{hashName.name: hash}
+			break
+		}
+	}
+	if job.IsSample {
+		if job.FileName != "" {
+			file["name"] = job.FileName
+			contributingProperties["name"] = job.FileName
+		}
+		if job.FileMimetype != "" {
+			file["mime_type"] = job.FileMimetype
+		}
+	}
+	file["id"] = scoID("file", contributingProperties)
+
+	hashNamesFound := []string{}
+	for name := range hashes {
+		hashNamesFound = append(hashNamesFound, name)
+	}
+	sort.Strings(hashNamesFound)
+	comparisons := []string{}
+	for _, name := range hashNamesFound {
+		comparisons = append(comparisons, fmt.Sprintf("file:hashes.'%s' = '%s'", name, escapePattern(hashes[name])))
+	}
+	return file, "[" + strings.Join(comparisons, " OR ") + "]"
+}
+
+// escapePattern escapes a string to be put between quotes in a STIX pattern.
+func escapePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
+// noteContent is the analyzer report as JSON, or its errors if it did not succeed.
+func noteContent(report *gointelowl.Report) string {
+	if !report.Status.IsSuccessful() && len(report.Errors) > 0 {
+		return strings.Join(report.Errors, "\n")
+	}
+	reportJson, err := json.Marshal(report.Report)
+	if err != nil || len(report.Report) == 0 {
+		return "{}"
+	}
+	return string(reportJson)
+}
+
+// sortRelationshipsLast moves the relationships to the end of the bundle, after the objects they link.
+func sortRelationshipsLast(objects []Object) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].Type() != "relationship" && objects[j].Type() == "relationship"
+	})
+}
+
+// YaraMalwareHook turns every Yara rule that matched into a malware, indicated by the job's indicator.
+func YaraMalwareHook(hookContext *HookContext) ([]Object, error) {
+	yaraReport := gointelowl.YaraReport{}
+	if err := hookContext.Report.Decode(&yaraReport); err != nil {
+		return nil, err
+	}
+	objects := []Object{}
+	seen := map[string]bool{}
+	for _, match := range yaraReport.Matches() {
+		if match.Match == "" || seen[match.Match] {
+			continue
+		}
+		seen[match.Match] = true
+		malware := hookContext.NewObject("malware", "yara/"+match.Match)
+		malware["name"] = match.Match
+		malware["is_family"] = true
+		malware["malware_types"] = []string{"unknown"}
+		if description, ok := match.Meta["description"].(string); ok && description != "" {
+			malware["description"] = description
+		}
+		objects = append(objects, malware)
+		if strings.HasPrefix(hookContext.ObservableRef, "indicator--") {
+			objects = append(objects, hookContext.Relationship(hookContext.ObservableRef, "indicates", malware.ID()))
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,126 @@
+// Package stix exports IntelOwl jobs as STIX 2.1 bundles, ready to be ingested by a threat intelligence platform.
+//
+// STIX 2.1 specification: https://docs.oasis-open.org/cti/stix/v2.1/stix-v2.1.html
+package stix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+	"github.com/intelowlproject/go-intelowl/internal/export"
+)
+
+// SpecVersion is the STIX version of the exported objects.
+const SpecVersion = "2.1"
+
+// TimestampFormat is how STIX timestamps are written: UTC with millisecond precision.
+const TimestampFormat = "2006-01-02T15:04:05.000Z"
+
+// Object represents a STIX object (SDO, SCO, SRO or marking definition) as its JSON properties.
+// Being a map, it holds any object type and any custom property.
+type Object map[string]interface{}
+
+// ID returns the id property of the object.
+func (object Object) ID() string {
+	id, _ := object["id"].(string)
+	return id
+}
+
+// Type returns the type property of the object.
+func (object Object) Type() string {
+	objectType, _ := object["type"].(string)
+	return objectType
+}
+
+// Bundle represents a STIX bundle, the envelope of the exported objects.
+type Bundle struct {
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Objects []Object `json:"objects"`
+}
+
+// ObjectsOfType returns the objects of the bundle of the given type.
+func (bundle *Bundle) ObjectsOfType(objectType string) []Object {
+	objects := []Object{}
+	for _, object := range bundle.Objects {
+		if object.Type() == objectType {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// CheckRequiredProperties checks the properties of the objects of the bundle, see CheckRequiredProperties.
+func (bundle *Bundle) CheckRequiredProperties() error {
+	bundleJson, err := json.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+	return CheckRequiredProperties(bundleJson)
+}
+
+// tlpMarkingDefinitions are the TLP marking definitions predefined by the STIX 2.1 specification.
+var tlpMarkingDefinitions = map[gointelowl.TLP]Object{
+	gointelowl.WHITE: tlpMarkingDefinition("613f2e26-407d-48c7-9eca-b8e91df99dc9", "white"),
+	gointelowl.GREEN: tlpMarkingDefinition("34098fce-860f-48ae-8e50-ebd3cc5e41da", "green"),
+	gointelowl.AMBER: tlpMarkingDefinition("f88d31f6-486f-44da-b317-01333bde0b82", "amber"),
+	gointelowl.RED:   tlpMarkingDefinition("5e57c739-391a-4eb3-b6be-7d15ca92d5ed", "red"),
+}
+
+// tlpMarkingDefinition makes one of the predefined TLP marking definitions.
+func tlpMarkingDefinition(uuid string, tlp string) Object {
+	return Object{
+		"type":            "marking-definition",
+		"spec_version":    SpecVersion,
+		"id":              "marking-definition--" + uuid,
+		"created":         "2017-01-20T00:00:00.000Z",
+		"definition_type": "tlp",
+		"name":            "TLP:" + map[string]string{"white": "WHITE", "green": "GREEN", "amber": "AMBER", "red": "RED"}[tlp],
+		"definition":      map[string]interface{}{"tlp": tlp},
+	}
+}
+
+// TLPMarkingDefinition returns the STIX marking definition of a TLP e.g: the one with the id
+// "marking-definition--f88d31f6-486f-44da-b317-01333bde0b82" for AMBER.
+func TLPMarkingDefinition(tlp gointelowl.TLP) (Object, error) {
+	markingDefinition, ok := tlpMarkingDefinitions[tlp]
+	if !ok {
+		return nil, fmt.Errorf("Unknown TLP %d", tlp)
+	}
+	// * a copy, the predefined ones must not be changed
+	markingDefinitionCopy := Object{}
+	for key, value := range markingDefinition {
+		markingDefinitionCopy[key] = value
+	}
+	return markingDefinitionCopy, nil
+}
+
+// scoNamespace is the namespace of the deterministic identifiers of the STIX Cyber-observable Objects.
+var scoNamespace = export.MustParseUUID("00abedb4-aa42-466c-9c01-fed23315a9b7")
+
+// exportNamespace is the namespace of the identifiers of the other exported objects,
+// so that exporting a job twice gives the same identifiers.
+var exportNamespace = export.UUIDv5(export.URLNamespace, "https://github.com/intelowlproject/go-intelowl/stix")
+
+// scoID makes the deterministic identifier of a STIX Cyber-observable Object out of its ID contributing properties.
+func scoID(objectType string, contributingProperties map[string]interface{}) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	// * encoding a map sorts its keys, as the JSON canonicalization the specification asks for
+	_ = encoder.Encode(contributingProperties)
+	return objectType + "--" + export.FormatUUID(export.UUIDv5(scoNamespace, string(bytes.TrimRight(buffer.Bytes(), "\n"))))
+}
+
+// objectID makes the identifier of an exported object out of what makes it unique.
+func objectID(objectType string, name string) string {
+	return objectType + "--" + export.FormatUUID(export.UUIDv5(exportNamespace, objectType+"/"+name))
+}
+
+// formatTimestamp writes a time as a STIX timestamp.
+func formatTimestamp(timestamp time.Time) string {
+	return timestamp.UTC().Format(TimestampFormat)
+}
@@ -3,10 +3,17 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/intelowlproject/go-intelowl/constants"
 	"github.com/intelowlproject/go-intelowl/gointelowl"
@@ -214,3 +221,307 @@ func TestCreateMultipleFilesAnalysis(t *testing.T) {
 	}
 
 }
+
+func TestAnalyzeObservableAndWait(t *testing.T) {
+	jobJson := `{"id":300,"status":"reported_without_fails","observable_name":"8.8.8.8","analyzer_reports":[{"name":"Classic_DNS","status":"SUCCESS","report":{"resolutions":["dns.google"]},"errors":[],"type":"analyzer"}],"connector_reports":[]}`
+	job := gointelowl.Job{}
+	if unmarshalError := json.Unmarshal([]byte(jobJson), &job); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"job_id":300,"status":"accepted","warnings":[],"analyzers_running":["Classic_DNS"],"connectors_running":[]}`)
+	})
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 300), func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, jobJson)
+	})
+	ctx := context.Background()
+	gottenJob, err := client.AnalyzeObservableAndWait(ctx, &gointelowl.ObservableAnalysisParams{
+		ObservableName:           "8.8.8.8",
+		ObservableClassification: "ip",
+	}, &gointelowl.WaitOptions{InitialInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	testWantData(t, &job, gottenJob)
+}
+
+func TestAnalyzeMultipleObservablesAndWait(t *testing.T) {
+	reportedJobJson := `{"id":301,"status":"reported_without_fails","observable_name":"8.8.8.8","analyzer_reports":[],"connector_reports":[]}`
+	failedJobJson := `{"id":302,"status":"failed","observable_name":"8.8.8.7","analyzer_reports":[],"connector_reports":[],"errors":["boom"]}`
+	otherReportedJobJson := `{"id":303,"status":"reported_without_fails","observable_name":"8.8.8.8","analyzer_reports":[],"connector_reports":[]}`
+	reportedJob := gointelowl.Job{}
+	if unmarshalError := json.Unmarshal([]byte(reportedJobJson), &reportedJob); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	otherReportedJob := gointelowl.Job{}
+	if unmarshalError := json.Unmarshal([]byte(otherReportedJobJson), &otherReportedJob); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_OBSERVABLES_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `{"count":3,"results":[{"job_id":301,"status":"accepted"},{"job_id":302,"status":"accepted"},{"job_id":303,"status":"accepted"}]}`)
+	})
+	// * with a single worker the jobs are followed one at a time
+	var mutex sync.Mutex
+	polling := 0
+	maxPolling := 0
+	for jobID, jobJson := range map[int]string{301: reportedJobJson, 302: failedJobJson, 303: otherReportedJobJson} {
+		jobJson := jobJson
+		apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, jobID), func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			polling++
+			if polling > maxPolling {
+				maxPolling = polling
+			}
+			mutex.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mutex.Lock()
+			polling--
+			mutex.Unlock()
+			fmt.Fprint(w, jobJson)
+		})
+	}
+	ctx := context.Background()
+	results, err := client.AnalyzeMultipleObservablesAndWait(ctx, &gointelowl.MultipleObservableAnalysisParams{
+		// * the same observable twice, e.g: with different tags
+		Observables: [][]string{{"ip", "8.8.8.8"}, {"ip", "8.8.8.7"}, {"ip", "8.8.8.8"}},
+	}, &gointelowl.WaitOptions{InitialInterval: time.Millisecond, MaxConcurrentWaits: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	testWantData(t, 3, len(results))
+	testWantData(t, 1, maxPolling)
+	testWantData(t, &gointelowl.AnalysisResult{Input: "8.8.8.8", JobID: 301, Job: &reportedJob}, results[0])
+	testWantData(t, &gointelowl.AnalysisResult{Input: "8.8.8.8", JobID: 303, Job: &otherReportedJob}, results[2])
+	failedResult := results[1]
+	testWantData(t, "8.8.8.7", failedResult.Input)
+	jobError := &gointelowl.JobError{}
+	if !errors.As(failedResult.Error, &jobError) {
+		t.Fatalf("Expected a JobError, got: %v", failedResult.Error)
+	}
+	testWantData(t, &gointelowl.JobError{JobID: 302, Status: "failed", Errors: []string{"boom"}}, jobError)
+	// * both results of the duplicated observable are kept
+	resultsByInput := results.ByInput()
+	testWantData(t, 2, len(resultsByInput))
+	testWantData(t, []*gointelowl.AnalysisResult{results[0], results[2]}, resultsByInput["8.8.8.8"])
+	testWantData(t, []*gointelowl.AnalysisResult{failedResult}, resultsByInput["8.8.8.7"])
+}
+
+func TestCreateMultipleFilesAnalysisStreaming(t *testing.T) {
+	fileContent, _ := ioutil.ReadFile(path.Join("./testFiles/", "fileForAnalysis.txt"))
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_FILES_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		// * the size of a streamed body is known when the size of every file is
+		testWantData(t, true, r.ContentLength > 0)
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("Could not parse the multipart body: %s", err)
+		}
+		testWantData(t, []string{"WHITE"}, r.MultipartForm.Value["tlp"])
+		testWantData(t, []string{"File_Info", "Strings_Info"}, r.MultipartForm.Value["analyzers_requested"])
+		testWantData(t, 2, len(r.MultipartForm.File["files"]))
+		for _, fileHeader := range r.MultipartForm.File["files"] {
+			file, _ := fileHeader.Open()
+			uploadedContent, _ := ioutil.ReadAll(file)
+			file.Close()
+			testWantData(t, string(fileContent), string(uploadedContent))
+		}
+		fmt.Fprint(w, `{"count":2,"results":[{"job_id":1,"status":"accepted"},{"job_id":2,"status":"accepted"}]}`)
+	})
+	file, _ := os.Open(path.Join("./testFiles/", "fileForAnalysis.txt"))
+	defer file.Close()
+	file2, _ := os.Open(path.Join("./testFiles/", "fileForAnalysis.txt"))
+	defer file2.Close()
+	sent := []int64{}
+	ctx := context.Background()
+	_, err := client.CreateMultipleFileAnalysis(ctx, &gointelowl.MultipleFileAnalysisParams{
+		BasicAnalysisParams: gointelowl.BasicAnalysisParams{
+			Tlp:                gointelowl.WHITE,
+			AnalyzersRequested: []string{"File_Info", "Strings_Info"},
+		},
+		Files: []*os.File{file, file2},
+		OnProgress: func(fileName string, bytesSent int64, total int64) {
+			testWantData(t, "fileForAnalysis.txt", fileName)
+			testWantData(t, int64(len(fileContent)), total)
+			sent = append(sent, bytesSent)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	testWantData(t, true, len(sent) >= 2)
+	testWantData(t, int64(len(fileContent)), sent[len(sent)-1])
+}
+
+func TestCreateFileAnalysisCancellation(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "bigFile.bin")
+	if err := ioutil.WriteFile(filePath, make([]byte, 8<<20), 0600); err != nil {
+		t.Fatalf("Could not write the file: %s", err)
+	}
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, `{"job_id":1,"status":"accepted"}`)
+	})
+	file, _ := os.Open(filePath)
+	defer file.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var lastSent int64
+	_, err := client.CreateFileAnalysis(ctx, &gointelowl.FileAnalysisParams{
+		File: file,
+		OnProgress: func(fileName string, bytesSent int64, total int64) {
+			lastSent = bytesSent
+			// * cancelling as soon as the upload has started
+			cancel()
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the upload to be cancelled, got: %v", err)
+	}
+	if lastSent >= 8<<20 {
+		t.Fatalf("The whole file was sent before cancelling")
+	}
+}
+
+func TestCreateFileAnalysisFromSource(t *testing.T) {
+	sampleContent := "This is an in-memory sample"
+	testCases := map[string]struct {
+		source            *gointelowl.FileSource
+		wantContentType   string
+		wantContentLength bool
+	}{
+		"bytes": {
+			source:            gointelowl.NewFileSourceFromBytes("attachment.eml", []byte(sampleContent)),
+			wantContentType:   "application/octet-stream",
+			wantContentLength: true,
+		},
+		"reader": {
+			source: &gointelowl.FileSource{
+				Name:        "attachment.eml",
+				Reader:      ioutil.NopCloser(strings.NewReader(sampleContent)),
+				ContentType: "message/rfc822",
+			},
+			wantContentType:   "message/rfc822",
+			wantContentLength: false,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				testWantData(t, testCase.wantContentLength, r.ContentLength > 0)
+				file, fileHeader, err := r.FormFile("file")
+				if err != nil {
+					t.Fatalf("Could not read the uploaded file: %s", err)
+				}
+				defer file.Close()
+				uploadedContent, _ := ioutil.ReadAll(file)
+				testWantData(t, sampleContent, string(uploadedContent))
+				testWantData(t, "attachment.eml", fileHeader.Filename)
+				testWantData(t, testCase.wantContentType, fileHeader.Header.Get("Content-Type"))
+				fmt.Fprint(w, `{"job_id":1,"status":"accepted"}`)
+			})
+			ctx := context.Background()
+			analysisResponse, err := client.CreateFileAnalysis(ctx, &gointelowl.FileAnalysisParams{Source: testCase.source})
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			testWantData(t, 1, analysisResponse.JobID)
+		})
+	}
+	t.Run("fileAndSource", func(t *testing.T) {
+		client, _, closeServer := setup()
+		defer closeServer()
+		file, _ := os.Open(path.Join("./testFiles/", "fileForAnalysis.txt"))
+		defer file.Close()
+		_, err := client.CreateFileAnalysis(context.Background(), &gointelowl.FileAnalysisParams{
+			File:   file,
+			Source: gointelowl.NewFileSourceFromBytes("sample", []byte(sampleContent)),
+		})
+		testWantData(t, true, err != nil)
+	})
+}
+
+// Helper test
+// Testing that no goroutine has been left behind, e.g: one streaming the body of a request that is never sent
+func testNoGoroutineLeft(t *testing.T, baseline int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if goroutines := runtime.NumGoroutine(); goroutines > baseline {
+		t.Fatalf("%d goroutines left behind", goroutines-baseline)
+	}
+}
+
+func TestCreateFileAnalysisNotSent(t *testing.T) {
+	t.Run("queuedBehindLimiter", func(t *testing.T) {
+		client, apiHandler, closeServer := setupWithOptions(&gointelowl.IntelOwlClientOptions{
+			RateLimit: &gointelowl.RateLimit{MaxInFlight: 1},
+		})
+		defer closeServer()
+		received := make(chan struct{})
+		unblock := make(chan struct{})
+		apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+			received <- struct{}{}
+			<-unblock
+			fmt.Fprint(w, `[]`)
+		})
+		go client.TagService.List(context.Background())
+		<-received
+		defer close(unblock)
+
+		baseline := runtime.NumGoroutine()
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error)
+		go func() {
+			_, err := client.CreateFileAnalysis(ctx, &gointelowl.FileAnalysisParams{
+				Source: gointelowl.NewFileSourceFromReader("queued.bin", strings.NewReader("queued sample"), 0),
+			})
+			errs <- err
+		}()
+		// * waiting for the upload to queue up behind the first request
+		deadline := time.Now().Add(time.Second)
+		for client.QueueDepth() != 1 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected the upload to be cancelled, got: %v", err)
+		}
+		testNoGoroutineLeft(t, baseline)
+	})
+	t.Run("middlewareShortCircuit", func(t *testing.T) {
+		client, apiHandler, closeServer := setup()
+		defer closeServer()
+		apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+			t.Fatalf("The request should not reach the server")
+		})
+		client.Use(func(next gointelowl.Handler) gointelowl.Handler {
+			return func(ctx context.Context, call *gointelowl.Call) (*gointelowl.SuccessResponse, error) {
+				return &gointelowl.SuccessResponse{StatusCode: http.StatusOK, Data: []byte(`{"job_id":1,"status":"accepted"}`)}, nil
+			}
+		})
+		baseline := runtime.NumGoroutine()
+		analysisResponse, err := client.CreateFileAnalysis(context.Background(), &gointelowl.FileAnalysisParams{
+			Source: gointelowl.NewFileSourceFromReader("mocked.bin", strings.NewReader("mocked sample"), 0),
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		testWantData(t, 1, analysisResponse.JobID)
+		testNoGoroutineLeft(t, baseline)
+	})
+}
@@ -69,6 +69,7 @@ func TestAnalyzerServiceGetConfigs(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusInternalServerError,
 			Message:    serverErrorString,
+			Errors:     []string{"Error occurred by the server"},
 		},
 	}
 	testCases["badGateway"] = TestData{
@@ -78,6 +79,7 @@ func TestAnalyzerServiceGetConfigs(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusBadGateway,
 			Message:    badGatewayErrorString,
+			Errors:     []string{"Bad Gateway"},
 		},
 	}
 	for name, testCase := range testCases {
@@ -113,6 +115,7 @@ func TestAnalyzerServiceHealthCheck(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"errors": {"detail": "Analyzer doesn't exist"}}`,
+			Detail:     "Analyzer doesn't exist",
 		},
 	}
 	for name, testCase := range testCases {
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/intelowlproject/go-intelowl/constants"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+func TestClassifyObservable(t *testing.T) {
+	// * table test case
+	testCases := map[string]TestData{
+		"ipv4":          {Input: "8.8.8.8", Want: gointelowl.IPClassification},
+		"ipv6":          {Input: "2001:4860:4860::8888", Want: gointelowl.IPClassification},
+		"ipv4Mapped":    {Input: "::ffff:8.8.8.8", Want: gointelowl.IPClassification},
+		"notAnIp":       {Input: "256.1.1.1", Want: gointelowl.DomainClassification},
+		"domain":        {Input: "dns.google.com", Want: gointelowl.DomainClassification},
+		"dottedDomain":  {Input: ".google.com", Want: gointelowl.DomainClassification},
+		"upperDomain":   {Input: "DNS.Google.COM", Want: gointelowl.DomainClassification},
+		"url":           {Input: "https://www.google.com/search?q=intelowl", Want: gointelowl.URLClassification},
+		"urlWithPort":   {Input: "http://evil.example.com:8080/payload.exe", Want: gointelowl.URLClassification},
+		"md5":           {Input: "40ff44d9e619b17524bf3763204f9cbb", Want: gointelowl.HashClassification},
+		"sha1":          {Input: "da39a3ee5e6b4b0d3255bfef95601890afd80709", Want: gointelowl.HashClassification},
+		"sha256":        {Input: "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855", Want: gointelowl.HashClassification},
+		"hexOfBadSize":  {Input: "40ff44d9e619b17524bf3763204f9cb", Want: gointelowl.GenericClassification},
+		"generic":       {Input: "ransomware", Want: gointelowl.GenericClassification},
+		"email":         {Input: "admin@example.com", Want: gointelowl.GenericClassification},
+		"surroundSpace": {Input: " 1.1.1.1 ", Want: gointelowl.IPClassification},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			observable, ok := testCase.Input.(string)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			testWantData(t, testCase.Want, gointelowl.ClassifyObservable(observable))
+		})
+	}
+}
+
+func TestHashAlgorithm(t *testing.T) {
+	testWantData(t, gointelowl.MD5Hash, gointelowl.HashAlgorithm("40ff44d9e619b17524bf3763204f9cbb"))
+	testWantData(t, gointelowl.SHA1Hash, gointelowl.HashAlgorithm("da39a3ee5e6b4b0d3255bfef95601890afd80709"))
+	testWantData(t, gointelowl.SHA256Hash, gointelowl.HashAlgorithm("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+	testWantData(t, gointelowl.SHA512Hash, gointelowl.HashAlgorithm("cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"))
+	testWantData(t, "", gointelowl.HashAlgorithm("not a hash"))
+	testWantData(t, 4, gointelowl.IPVersion("8.8.8.8"))
+	testWantData(t, 6, gointelowl.IPVersion("2001:db8::1"))
+	testWantData(t, 0, gointelowl.IPVersion("google.com"))
+}
+
+func TestObservableAnalysisClassification(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gointelowl.ObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Fatalf("Could not decode the request: %s", err)
+		}
+		testWantData(t, gointelowl.DomainClassification, params.ObservableClassification)
+		fmt.Fprint(w, `{"job_id":1,"status":"accepted"}`)
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_OBSERVABLES_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gointelowl.MultipleObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Fatalf("Could not decode the request: %s", err)
+		}
+		testWantData(t, [][]string{
+			{"generic", "8.8.8.8"},
+			{"ip", "1.1.1.1"},
+			{"url", "https://example.com/path"},
+			{"hash", "40ff44d9e619b17524bf3763204f9cbb"},
+		}, params.Observables)
+		fmt.Fprint(w, `{"count":4,"results":[]}`)
+	})
+	ctx := context.Background()
+	params := &gointelowl.ObservableAnalysisParams{ObservableName: "dns.google"}
+	if _, err := client.CreateObservableAnalysis(ctx, params); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// * the caller's params are left untouched
+	testWantData(t, "", params.ObservableClassification)
+	_, err := client.CreateMultipleObservableAnalysis(ctx, &gointelowl.MultipleObservableAnalysisParams{
+		Observables:     [][]string{{"generic", "8.8.8.8"}, {"", "1.1.1.1"}, {"https://example.com/path"}},
+		ObservableNames: []string{"40ff44d9e619b17524bf3763204f9cbb"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
@@ -71,6 +71,7 @@ func TestConnectorServiceHealthCheck(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"errors": {"detail": "Connector doesn't exist"}}`,
+			Detail:     "Connector doesn't exist",
 		},
 	}
 	for name, testCase := range testCases {
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+func TestDiffJobs(t *testing.T) {
+	oldJobJson := `{"id":1,"status":"reported_with_fails","errors":["Timeout"],
+		"analyzer_reports":[
+			{"name":"Classic_DNS","status":"SUCCESS","report":{"resolutions":["1.2.3.4"],"observable":"evil.com","timestamp":"2022-07-15T20:25:47Z"},"errors":[]},
+			{"name":"AbuseIPDB","status":"FAILED","report":{},"errors":["No API key"]},
+			{"name":"Phishstats","status":"SUCCESS","report":{"results":[]},"errors":[]}
+		],
+		"connector_reports":[{"name":"MISP","status":"SUCCESS","report":{"id":"10"},"errors":[]}]}`
+	newJobJson := `{"id":2,"status":"reported_without_fails","errors":[],
+		"analyzer_reports":[
+			{"name":"Classic_DNS","status":"SUCCESS","report":{"resolutions":["5.6.7.8","1.2.3.4"],"observable":"evil.com","timestamp":"2022-07-20T10:00:00Z"},"errors":[]},
+			{"name":"AbuseIPDB","status":"SUCCESS","report":{"data":{"abuseConfidenceScore":100,"scan_id":"b"}},"errors":[]},
+			{"name":"Yara","status":"SUCCESS","report":{},"errors":[]}
+		],
+		"connector_reports":[{"name":"MISP","status":"SUCCESS","report":{"id":"11"},"errors":[]}]}`
+	oldJob := gointelowl.Job{}
+	newJob := gointelowl.Job{}
+	if err := json.Unmarshal([]byte(oldJobJson), &oldJob); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if err := json.Unmarshal([]byte(newJobJson), &newJob); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+
+	jobDiff := gointelowl.DiffJobs(&oldJob, &newJob, &gointelowl.DiffOptions{
+		IgnorePaths:       []string{"**.timestamp", "**.scan_id"},
+		ReportIgnorePaths: map[string][]string{"MISP": {"id"}},
+	})
+	want := &gointelowl.JobDiff{
+		OldJobID:          1,
+		NewJobID:          2,
+		OldStatus:         gointelowl.JobStatusReportedWithFails,
+		NewStatus:         gointelowl.JobStatusReportedWithoutFails,
+		AnalyzersAdded:    []string{"Yara"},
+		AnalyzersRemoved:  []string{"Phishstats"},
+		ConnectorsAdded:   []string{},
+		ConnectorsRemoved: []string{},
+		ErrorsIntroduced:  []string{},
+		ErrorsResolved:    []string{"Timeout"},
+		Reports: []gointelowl.ReportDiff{
+			{
+				Name:             "AbuseIPDB",
+				Type:             "analyzer",
+				OldStatus:        gointelowl.ReportStatusFailed,
+				NewStatus:        gointelowl.ReportStatusSuccess,
+				ErrorsIntroduced: []string{},
+				ErrorsResolved:   []string{"No API key"},
+				Changes: []gointelowl.ValueChange{
+					{Path: "data", Kind: gointelowl.ValueAdded, New: map[string]interface{}{"abuseConfidenceScore": 100.0, "scan_id": "b"}},
+				},
+			},
+			{
+				Name:             "Classic_DNS",
+				Type:             "analyzer",
+				OldStatus:        gointelowl.ReportStatusSuccess,
+				NewStatus:        gointelowl.ReportStatusSuccess,
+				ErrorsIntroduced: []string{},
+				ErrorsResolved:   []string{},
+				Changes: []gointelowl.ValueChange{
+					{Path: "resolutions.0", Kind: gointelowl.ValueChanged, Old: "1.2.3.4", New: "5.6.7.8"},
+					{Path: "resolutions.1", Kind: gointelowl.ValueAdded, New: "1.2.3.4"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, jobDiff); diff != "" {
+		t.Fatalf(diff)
+	}
+
+	wantText := `Job #1 -> #2
+status: reported_with_fails -> reported_without_fails
+analyzers added: Yara
+analyzers removed: Phishstats
+error resolved: Timeout
+analyzer AbuseIPDB:
+  status: FAILED -> SUCCESS
+  error resolved: No API key
+  + data: {"abuseConfidenceScore":100,"scan_id":"b"}
+analyzer Classic_DNS:
+  ~ resolutions.0: "1.2.3.4" -> "5.6.7.8"
+  + resolutions.1: "1.2.3.4"
+`
+	if diff := cmp.Diff(wantText, jobDiff.Text()); diff != "" {
+		t.Fatalf(diff)
+	}
+
+	jobDiffJson, err := json.Marshal(jobDiff)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	decodedJobDiff := gointelowl.JobDiff{}
+	if err := json.Unmarshal(jobDiffJson, &decodedJobDiff); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if diff := cmp.Diff(want, &decodedJobDiff); diff != "" {
+		t.Fatalf(diff)
+	}
+}
+
+func TestDiffJobsWithoutChanges(t *testing.T) {
+	job := loadReportedJob(t)
+	jobDiff := gointelowl.DiffJobs(job, job.Transform(func(value string) string { return value }), nil)
+	if jobDiff.HasChanges() {
+		t.Fatalf("Unexpected changes: %s", jobDiff.Text())
+	}
+	if diff := cmp.Diff("Job #42 -> #42\nno changes\n", jobDiff.Text()); diff != "" {
+		t.Fatalf(diff)
+	}
+
+	changedJob := job.Transform(func(value string) string {
+		if value == "Windows_Trojan_Generic" {
+			return "Windows_Trojan_Other"
+		}
+		return value
+	})
+	jobDiff = gointelowl.DiffJobs(job, changedJob, &gointelowl.DiffOptions{IgnorePaths: []string{"*.*.match"}})
+	if jobDiff.HasChanges() {
+		t.Fatalf("Ignored changes: %s", jobDiff.Text())
+	}
+	// * the dots of the key are escaped
+	jobDiff = gointelowl.DiffJobs(job, changedJob, &gointelowl.DiffOptions{IgnorePaths: []string{`https://github\.com/elastic/protections-artifacts.*.match`}})
+	if jobDiff.HasChanges() {
+		t.Fatalf("Ignored changes: %s", jobDiff.Text())
+	}
+	jobDiff = gointelowl.DiffJobs(job, changedJob, &gointelowl.DiffOptions{IgnorePaths: []string{"https://github.com/elastic/protections-artifacts.*.match"}})
+	if !jobDiff.HasChanges() {
+		t.Fatalf("Expected the unescaped path not to match")
+	}
+	jobDiff = gointelowl.DiffJobs(job, changedJob, nil)
+	if len(jobDiff.Reports) != 1 || jobDiff.Reports[0].Changes[0].Path != "https://github.com/elastic/protections-artifacts.0.match" {
+		t.Fatalf("Unexpected changes: %s", jobDiff.Text())
+	}
+}
+
+func TestDiffJobsNullValues(t *testing.T) {
+	oldJob := gointelowl.Job{}
+	newJob := gointelowl.Job{}
+	if err := json.Unmarshal([]byte(`{"id":1,"analyzer_reports":[{"name":"AbuseIPDB","status":"SUCCESS","report":{"score":null,"country":"IT"},"errors":[]}]}`), &oldJob); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if err := json.Unmarshal([]byte(`{"id":2,"analyzer_reports":[{"name":"AbuseIPDB","status":"SUCCESS","report":{"score":100,"country":null},"errors":[]}]}`), &newJob); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	jobDiff := gointelowl.DiffJobs(&oldJob, &newJob, nil)
+	changesJson, err := json.Marshal(jobDiff.Reports[0].Changes)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	// * a change from or to null keeps its null value
+	want := `[{"path":"country","kind":"changed","old":"IT","new":null},{"path":"score","kind":"changed","old":null,"new":100}]`
+	if diff := cmp.Diff(want, string(changesJson)); diff != "" {
+		t.Fatalf(diff)
+	}
+}
@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// * go test ./tests -update rewrites the golden files, e.g: -run TestStix for the STIX bundles only
+var update = flag.Bool("update", false, "update the golden files")
+
+// Helper test
+// Comparing the value, as indented JSON, with the golden file testFiles/<directory>/<goldenFileName>
+func compareWithGoldenFile(t *testing.T, value interface{}, directory string, goldenFileName string) {
+	t.Helper()
+	valueJson, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	valueJson = append(valueJson, '\n')
+	goldenFilePath := path.Join("./testFiles/", directory, goldenFileName)
+	if *update {
+		if err := os.WriteFile(goldenFilePath, valueJson, 0644); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+	}
+	goldenJson, err := os.ReadFile(goldenFilePath)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if diff := cmp.Diff(string(goldenJson), string(valueJson)); diff != "" {
+		t.Fatalf(diff)
+	}
+}
@@ -0,0 +1,413 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intelowlproject/go-intelowl/constants"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+func testRetryPolicy() *gointelowl.RetryPolicy {
+	retryPolicy := gointelowl.DefaultRetryPolicy()
+	retryPolicy.BaseDelay = time.Millisecond
+	retryPolicy.MaxDelay = 5 * time.Millisecond
+	return retryPolicy
+}
+
+func TestRetryPolicy(t *testing.T) {
+	// * table test case
+	testCases := map[string]struct {
+		retryPolicy  *gointelowl.RetryPolicy
+		statusCodes  []int
+		wantAttempts int
+		wantError    bool
+	}{
+		"noPolicy": {
+			retryPolicy:  nil,
+			statusCodes:  []int{http.StatusServiceUnavailable},
+			wantAttempts: 1,
+			wantError:    true,
+		},
+		"recovers": {
+			retryPolicy:  testRetryPolicy(),
+			statusCodes:  []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK},
+			wantAttempts: 3,
+			wantError:    false,
+		},
+		"givesUp": {
+			retryPolicy:  testRetryPolicy(),
+			statusCodes:  []int{http.StatusGatewayTimeout},
+			wantAttempts: 4,
+			wantError:    true,
+		},
+		"notRetryable": {
+			retryPolicy:  testRetryPolicy(),
+			statusCodes:  []int{http.StatusNotFound},
+			wantAttempts: 1,
+			wantError:    true,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gointelowl.IntelOwlClientOptions{
+				RetryPolicy: testCase.retryPolicy,
+			})
+			defer closeServer()
+			attempts := 0
+			apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+				statusCode := testCase.statusCodes[len(testCase.statusCodes)-1]
+				if attempts < len(testCase.statusCodes) {
+					statusCode = testCase.statusCodes[attempts]
+				}
+				attempts++
+				if statusCode == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(statusCode)
+				fmt.Fprint(w, `[]`)
+			})
+			ctx := context.Background()
+			_, err := client.TagService.List(ctx)
+			testWantData(t, testCase.wantError, err != nil)
+			testWantData(t, testCase.wantAttempts, attempts)
+		})
+	}
+}
+
+func TestRetryPolicyRetryAfter(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gointelowl.IntelOwlClientOptions{
+		RetryPolicy: testRetryPolicy(),
+	})
+	defer closeServer()
+	attempts := 0
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			// * an hour is way more than the MaxDelay of the policy
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := client.TagService.List(ctx)
+	testWantData(t, nil, err)
+	testWantData(t, 2, attempts)
+}
+
+func TestRetryPolicyJSON(t *testing.T) {
+	retryPolicy := gointelowl.DefaultRetryPolicy()
+	retryPolicyJson, err := json.Marshal(retryPolicy)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	// * the delays are in seconds, like the timeout of the client
+	if !strings.Contains(string(retryPolicyJson), `"base_delay":0.5,"max_delay":30`) {
+		t.Fatalf("Got %s, want the delays in seconds", retryPolicyJson)
+	}
+	decodedRetryPolicy := gointelowl.RetryPolicy{}
+	if err := json.Unmarshal(retryPolicyJson, &decodedRetryPolicy); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	testWantData(t, *retryPolicy, decodedRetryPolicy)
+
+	options := gointelowl.IntelOwlClientOptions{}
+	if err := json.Unmarshal([]byte(`{"retry_policy":{"max_attempts":3,"base_delay":2,"max_delay":0.25}}`), &options); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	testWantData(t, 3, options.RetryPolicy.MaxAttempts)
+	testWantData(t, 2*time.Second, options.RetryPolicy.BaseDelay)
+	testWantData(t, 250*time.Millisecond, options.RetryPolicy.MaxDelay)
+}
+
+func TestRetryPolicyNonIdempotent(t *testing.T) {
+	fileContent, _ := ioutil.ReadFile(path.Join("./testFiles/", "fileForAnalysis.txt"))
+	for name, retryNonIdempotent := range map[string]bool{"notAllowed": false, "allowed": true} {
+		t.Run(name, func(t *testing.T) {
+			retryPolicy := testRetryPolicy()
+			retryPolicy.RetryNonIdempotent = retryNonIdempotent
+			client, apiHandler, closeServer := setupWithOptions(&gointelowl.IntelOwlClientOptions{
+				RetryPolicy: retryPolicy,
+			})
+			defer closeServer()
+			attempts := 0
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				attempts++
+				// * every attempt has to carry the whole file
+				file, _, err := r.FormFile("file")
+				if err != nil {
+					t.Fatalf("Could not read the uploaded file: %s", err)
+				}
+				uploadedContent, _ := ioutil.ReadAll(file)
+				testWantData(t, string(fileContent), string(uploadedContent))
+				if attempts == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				fmt.Fprint(w, `{"job_id":1,"status":"accepted"}`)
+			})
+			file, _ := os.Open(path.Join("./testFiles/", "fileForAnalysis.txt"))
+			defer file.Close()
+			ctx := context.Background()
+			_, err := client.CreateFileAnalysis(ctx, &gointelowl.FileAnalysisParams{File: file})
+			if retryNonIdempotent {
+				testWantData(t, 2, attempts)
+				testWantData(t, nil, err)
+			} else {
+				testWantData(t, 1, attempts)
+				testWantData(t, true, err != nil)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gointelowl.IntelOwlClientOptions{
+		ServiceRateLimits: map[string]*gointelowl.RateLimit{
+			gointelowl.TagServiceName: {RequestsPerSecond: 20, Burst: 1},
+		},
+	})
+	defer closeServer()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	apiHandler.HandleFunc(constants.USER_DETAILS_URL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	})
+	ctx := context.Background()
+
+	// * other services are not throttled
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := client.UserService.Access(ctx); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("UserService should not be throttled, took %s", elapsed)
+	}
+
+	// * 1 request at once then 1 every 50ms
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.TagService.List(ctx); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("TagService should be throttled, took %s", elapsed)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gointelowl.IntelOwlClientOptions{
+		RateLimit: &gointelowl.RateLimit{MaxInFlight: 1},
+	})
+	defer closeServer()
+	received := make(chan struct{})
+	unblock := make(chan struct{})
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-unblock
+		fmt.Fprint(w, `[]`)
+	})
+	ctx := context.Background()
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := client.TagService.List(ctx)
+			errs <- err
+		}()
+	}
+	<-received
+	// * waiting for the second request to queue up behind the first one
+	deadline := time.Now().Add(time.Second)
+	for client.QueueDepth() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	testWantData(t, gointelowl.LimiterStats{Waiting: 1, InFlight: 1}, client.LimiterStats(""))
+	unblock <- struct{}{}
+	<-received
+	unblock <- struct{}{}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	testWantData(t, gointelowl.LimiterStats{}, client.LimiterStats(""))
+}
+
+func TestMaxInFlightCancelled(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gointelowl.IntelOwlClientOptions{
+		RateLimit: &gointelowl.RateLimit{RequestsPerSecond: 1, Burst: 2, MaxInFlight: 1},
+	})
+	defer closeServer()
+	received := make(chan struct{}, 1)
+	unblock := make(chan struct{}, 1)
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-unblock
+		fmt.Fprint(w, `[]`)
+	})
+	errs := make(chan error, 2)
+	go func() {
+		_, err := client.TagService.List(context.Background())
+		errs <- err
+	}()
+	<-received
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_, err := client.TagService.List(ctx)
+		errs <- err
+	}()
+	// * waiting for the second request to queue up behind the first one, holding the second token
+	deadline := time.Now().Add(time.Second)
+	for client.QueueDepth() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	unblock <- struct{}{}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// * the cancelled request gave its token back: no need to wait a second for the next one
+	unblock <- struct{}{}
+	timeoutCtx, cancelTimeout := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancelTimeout()
+	if _, err := client.TagService.List(timeoutCtx); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+// inFlightWriter records the requests in flight while the sample is written.
+type inFlightWriter struct {
+	client   *gointelowl.IntelOwlClient
+	inFlight []int
+}
+
+func (writer *inFlightWriter) Write(data []byte) (int, error) {
+	writer.inFlight = append(writer.inFlight, writer.client.LimiterStats("").InFlight)
+	return len(data), nil
+}
+
+func TestMaxInFlightStreamed(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gointelowl.IntelOwlClientOptions{
+		RateLimit: &gointelowl.RateLimit{MaxInFlight: 1},
+	})
+	defer closeServer()
+	apiHandler.HandleFunc(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "This is the sample")
+	})
+	writer := &inFlightWriter{client: &client}
+	if _, err := client.JobService.DownloadSampleTo(context.Background(), 1, writer, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// * the download holds its slot while its body is read, and gives it back once done
+	testWantData(t, []int{1}, writer.inFlight)
+	testWantData(t, gointelowl.LimiterStats{}, client.LimiterStats(""))
+}
+
+func TestIntelOwlErrorKinds(t *testing.T) {
+	// * table test case
+	testCases := map[string]struct {
+		statusCode int
+		body       string
+		wantKind   func(err error) bool
+		want       *gointelowl.IntelOwlError
+	}{
+		"notFound": {
+			statusCode: http.StatusNotFound,
+			body:       `{"detail":"Not found."}`,
+			wantKind:   gointelowl.IsNotFound,
+			want:       &gointelowl.IntelOwlError{Detail: "Not found."},
+		},
+		"unauthorized": {
+			statusCode: http.StatusUnauthorized,
+			body:       `{"detail":"Invalid token."}`,
+			wantKind:   gointelowl.IsUnauthorized,
+			want:       &gointelowl.IntelOwlError{Detail: "Invalid token."},
+		},
+		"forbidden": {
+			statusCode: http.StatusForbidden,
+			body:       `{"detail":"You do not have permission to perform this action."}`,
+			wantKind:   gointelowl.IsUnauthorized,
+			want:       &gointelowl.IntelOwlError{Detail: "You do not have permission to perform this action."},
+		},
+		"rateLimited": {
+			statusCode: http.StatusTooManyRequests,
+			body:       `{"detail":"Request was throttled. Expected available in 5 seconds."}`,
+			wantKind:   gointelowl.IsRateLimited,
+			want:       &gointelowl.IntelOwlError{Detail: "Request was throttled. Expected available in 5 seconds."},
+		},
+		"validation": {
+			statusCode: http.StatusBadRequest,
+			body:       `{"observable_name":["This field is required."],"runtime_configuration":{"Shodan":["Unknown parameter."]},"non_field_errors":["No analyzers can be run."]}`,
+			wantKind:   gointelowl.IsValidation,
+			want: &gointelowl.IntelOwlError{
+				Errors: []string{"No analyzers can be run."},
+				FieldErrors: map[string][]string{
+					"observable_name":              {"This field is required."},
+					"runtime_configuration.Shodan": {"Unknown parameter."},
+				},
+			},
+		},
+		"errorList": {
+			statusCode: http.StatusBadRequest,
+			body:       `{"errors":["first","second"]}`,
+			wantKind:   gointelowl.IsValidation,
+			want:       &gointelowl.IntelOwlError{Errors: []string{"first", "second"}},
+		},
+		"errorAndMessage": {
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":"Invalid playbook.","message":"Check the playbook name.","playbook":["Unknown playbook."]}`,
+			wantKind:   gointelowl.IsValidation,
+			want: &gointelowl.IntelOwlError{
+				Errors:      []string{"Invalid playbook.", "Check the playbook name."},
+				FieldErrors: map[string][]string{"playbook": {"Unknown playbook."}},
+			},
+		},
+		"notJson": {
+			statusCode: http.StatusBadGateway,
+			body:       `<html>Bad Gateway</html>`,
+			wantKind:   func(err error) bool { return !gointelowl.IsValidation(err) && !gointelowl.IsNotFound(err) },
+			want:       &gointelowl.IntelOwlError{},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testCase.statusCode)
+				fmt.Fprint(w, testCase.body)
+			})
+			_, err := client.TagService.List(context.Background())
+			testWantData(t, true, testCase.wantKind(err))
+			intelOwlError := &gointelowl.IntelOwlError{}
+			if !errors.As(err, &intelOwlError) {
+				t.Fatalf("Expected an IntelOwlError, got: %v", err)
+			}
+			want := testCase.want
+			want.StatusCode = testCase.statusCode
+			want.Message = testCase.body
+			testError(t, TestData{StatusCode: testCase.statusCode, Want: want}, intelOwlError)
+		})
+	}
+}
@@ -33,6 +33,31 @@ func setup() (testClient gointelowl.IntelOwlClient, apiHandler *http.ServeMux, c
 
 }
 
+// Setting up the router, test server, and a client configured through the given options
+func setupWithOptions(options *gointelowl.IntelOwlClientOptions) (testClient gointelowl.IntelOwlClient, apiHandler *http.ServeMux, closeServer func()) {
+
+	apiHandler = http.NewServeMux()
+
+	testServer := httptest.NewServer(apiHandler)
+
+	options.Url = testServer.URL
+	if options.Token == "" {
+		options.Token = "test-token"
+	}
+	testClient = gointelowl.NewIntelOwlClient(
+		options,
+		nil,
+		&gointelowl.LoggerParams{
+			File:      nil,
+			Formatter: nil,
+			Level:     logrus.DebugLevel,
+		},
+	)
+
+	return testClient, apiHandler, testServer.Close
+
+}
+
 // Helper test
 // Testing the request method is as expected
 func testMethod(t *testing.T, request *http.Request, wantedMethod string) {
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+	"github.com/intelowlproject/go-intelowl/ioc"
+)
+
+func TestRefang(t *testing.T) {
+	// * table test case
+	testCases := map[string]TestData{
+		"hxxp":        {Input: "hxxp://evil[.]com/path", Want: "http://evil.com/path"},
+		"hXXps":       {Input: "hXXps[://]evil[.]com", Want: "https://evil.com"},
+		"dot":         {Input: "evil(dot)com and evil[ dot ]net", Want: "evil.com and evil.net"},
+		"proseAt":     {Input: "meet me (at) noon", Want: "meet me @ noon"},
+		"proseDot":    {Input: "the end [dot] Next sentence", Want: "the end . Next sentence"},
+		"braces":      {Input: "8.8.8{.}8", Want: "8.8.8.8"},
+		"at":          {Input: "attacker[@]evil[.]com", Want: "attacker@evil.com"},
+		"notDefanged": {Input: "https://intelowl.com", Want: "https://intelowl.com"},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			text, ok := testCase.Input.(string)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			testWantData(t, testCase.Want, ioc.Refang(text))
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	text := `Incident notes: the host 10.0.0.5 beaconed to 185.220.101[.]4:443 and hxxps://evil-domain[.]com/payload.exe?id=1.
+Also saw evil(dot)org, mail from attacker@phish[.]net and admin@example.com. Hash 40FF44D9E619B17524BF3763204F9CBB and 40ff44d9e619b17524bf3763204f9cbb.
+Version 1.2.3.4.5, file invoice.pdf, IPv6 2001:4860:4860::8888 and fe80::1, time 12:30:45. See https://www.google.com/search?q=x, and 8.8.8.8.`
+	testCases := map[string]struct {
+		options *ioc.ExtractorOptions
+		want    []ioc.Indicator
+	}{
+		"default": {
+			options: nil,
+			want: []ioc.Indicator{
+				{Kind: ioc.IP, Value: "185.220.101.4", Classification: gointelowl.IPClassification},
+				{Kind: ioc.URL, Value: "https://evil-domain.com/payload.exe?id=1", Classification: gointelowl.URLClassification},
+				{Kind: ioc.Domain, Value: "evil.org", Classification: gointelowl.DomainClassification},
+				{Kind: ioc.Email, Value: "attacker@phish.net", Classification: gointelowl.GenericClassification},
+				{Kind: ioc.Hash, Value: "40ff44d9e619b17524bf3763204f9cbb", Classification: gointelowl.HashClassification},
+				{Kind: ioc.IP, Value: "2001:4860:4860::8888", Classification: gointelowl.IPClassification},
+				{Kind: ioc.URL, Value: "https://www.google.com/search?q=x", Classification: gointelowl.URLClassification},
+				{Kind: ioc.IP, Value: "8.8.8.8", Classification: gointelowl.IPClassification},
+			},
+		},
+		"customAllowlists": {
+			options: &ioc.ExtractorOptions{
+				AllowlistedNetworks: []string{"8.8.8.0/24"},
+				AllowlistedDomains:  []string{"google.com", "phish.net"},
+				Kinds:               []ioc.Kind{ioc.IP, ioc.URL, ioc.Email},
+			},
+			want: []ioc.Indicator{
+				{Kind: ioc.IP, Value: "10.0.0.5", Classification: gointelowl.IPClassification},
+				{Kind: ioc.IP, Value: "185.220.101.4", Classification: gointelowl.IPClassification},
+				{Kind: ioc.URL, Value: "https://evil-domain.com/payload.exe?id=1", Classification: gointelowl.URLClassification},
+				{Kind: ioc.Email, Value: "admin@example.com", Classification: gointelowl.GenericClassification},
+				{Kind: ioc.IP, Value: "2001:4860:4860::8888", Classification: gointelowl.IPClassification},
+				{Kind: ioc.IP, Value: "fe80::1", Classification: gointelowl.IPClassification},
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			extractor, err := ioc.NewExtractor(testCase.options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			testWantData(t, testCase.want, extractor.Extract(text))
+		})
+	}
+}
+
+func TestExtractObservableAnalysisParams(t *testing.T) {
+	extractor, err := ioc.NewExtractor(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	basicAnalysisParams := gointelowl.BasicAnalysisParams{
+		Tlp:        gointelowl.AMBER,
+		TagsLabels: []string{"phishing"},
+	}
+	params := extractor.ExtractObservableAnalysisParams("Block evil[.]com, 1.1.1[.]1 and evil[.]com", basicAnalysisParams)
+	testWantData(t, &gointelowl.MultipleObservableAnalysisParams{
+		BasicAnalysisParams: basicAnalysisParams,
+		Observables:         [][]string{{"domain", "evil.com"}, {"ip", "1.1.1.1"}},
+	}, params)
+
+	if _, err := ioc.NewExtractor(&ioc.ExtractorOptions{AllowlistedNetworks: []string{"not a network"}}); err == nil {
+		t.Fatalf("Expected an invalid network error")
+	}
+}
+
+func TestDefang(t *testing.T) {
+	// * table test case
+	testCases := map[string]TestData{
+		"url":                    {Input: "https://user@evil.com:8080/a.b?x=1, done", Want: "hxxps://user[@]evil[.]com:8080/a.b?x=1, done"},
+		"email":                  {Input: "mail bad@evil.co.uk", Want: "mail bad[@]evil[.]co[.]uk"},
+		"ipv4":                   {Input: "ip 8.8.8.8.", Want: "ip 8[.]8[.]8[.]8."},
+		"ipv6":                   {Input: "ip 2001:db8::1", Want: "ip 2001[:]db8[:][:]1"},
+		"domain":                 {Input: "resolve evil.com", Want: "resolve evil[.]com"},
+		"untouched":              {Input: "invoice.pdf at 12:30:45, version 1.2.3.4.5", Want: "invoice.pdf at 12:30:45, version 1.2.3.4.5"},
+		"defangedOnce":           {Input: "hxxps://evil[.]com", Want: "hxxps://evil[.]com"},
+		"dottedPath":             {Input: "get https://evil.com/v1.2/file.tar.gz?mirror=evil.io now", Want: "get hxxps://evil[.]com/v1.2/file.tar.gz?mirror=evil.io now"},
+		"dottedPathDefangedOnce": {Input: "get hxxps://evil[.]com/v1.2/file.tar.gz now", Want: "get hxxps://evil[.]com/v1.2/file.tar.gz now"},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			text, ok := testCase.Input.(string)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			defanged := ioc.Defang(text)
+			testWantData(t, testCase.Want, defanged)
+			testWantData(t, ioc.Refang(testCase.Want.(string)), ioc.Refang(defanged))
+		})
+	}
+}
+
+func TestDefangJob(t *testing.T) {
+	jobJson := `{"id":1,"observable_name":"evil.com","status":"reported_without_fails","analyzer_reports":[{"name":"Classic_DNS","status":"SUCCESS","report":{"resolutions":[{"data":"1.2.3.4","ttl":300}],"evil.com":true},"errors":["Could not reach https://evil.com/"]}],"connector_reports":[]}`
+	job := gointelowl.Job{}
+	if unmarshalError := json.Unmarshal([]byte(jobJson), &job); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	original := gointelowl.Job{}
+	json.Unmarshal([]byte(jobJson), &original)
+
+	defangedJob := ioc.DefangJob(&job)
+	testWantData(t, "evil[.]com", defangedJob.ObservableName)
+	testWantData(t, map[string]interface{}{
+		"resolutions": []interface{}{map[string]interface{}{"data": "1[.]2[.]3[.]4", "ttl": float64(300)}},
+		// * a report keyed by a domain is defanged too
+		"evil[.]com": true,
+	}, defangedJob.AnalyzerReports[0].Report)
+	testWantData(t, []string{"Could not reach hxxps://evil[.]com/"}, defangedJob.AnalyzerReports[0].Errors)
+	// * the original job is left untouched
+	testWantData(t, original, job)
+	testWantData(t, &original, ioc.RefangJob(defangedJob))
+}
+
+func TestDefangJobCollidingKeys(t *testing.T) {
+	jobJson := `{"id":1,"observable_name":"evil.com","status":"reported_without_fails","analyzer_reports":[{"name":"Classic_DNS","status":"SUCCESS","report":{"evil.com":1,"evil[.]com":2,"evil[.]com (2)":3,"www.evil.com":4}}],"connector_reports":[]}`
+	job := gointelowl.Job{}
+	if unmarshalError := json.Unmarshal([]byte(jobJson), &job); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	// * the keys left as they are keep their name, the others get a suffix in the order of their original keys
+	want := map[string]interface{}{
+		"evil[.]com":       float64(2),
+		"evil[.]com (2)":   float64(3),
+		"evil[.]com (3)":   float64(1),
+		"www[.]evil[.]com": float64(4),
+	}
+	// * the same report is always defanged the same way
+	for attempt := 0; attempt < 10; attempt++ {
+		testWantData(t, want, ioc.DefangJob(&job).AnalyzerReports[0].Report)
+	}
+}
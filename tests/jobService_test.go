@@ -1,11 +1,19 @@
 package tests
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"net/http"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/intelowlproject/go-intelowl/constants"
 	"github.com/intelowlproject/go-intelowl/gointelowl"
@@ -67,6 +75,7 @@ func TestJobServiceGet(t *testing.T) {
 			Want: &gointelowl.IntelOwlError{
 				StatusCode: http.StatusNotFound,
 				Message:    `{"detail":"Not found."}`,
+				Detail:     "Not found.",
 			},
 		}
 		for name, testCase := range testCases {
@@ -111,6 +120,7 @@ func TestJobServiceDownloadSample(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusBadRequest,
 			Message:    doesNotHaveASampleResponseJsonString,
+			Detail:     "Requested job does not have a sample associated with it.",
 		},
 	}
 	for name, testCase := range testCases {
@@ -137,6 +147,200 @@ func TestJobServiceDownloadSample(t *testing.T) {
 	}
 }
 
+func TestJobServiceDownloadSampleTo(t *testing.T) {
+	sampleString := "This is the sample"
+	sampleMd5 := "3f0e0383a916de66b7be80ff4a08a23c"
+	sampleSha256 := "a173394f47106868ca9b7f62325d4a911bdeae7a3cafd6e4beffd946ba353f27"
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      gointelowl.SampleDownloadOptions{},
+		Data:       sampleString,
+		StatusCode: http.StatusOK,
+		Want: &gointelowl.SampleDownloadResult{
+			Size:   int64(len(sampleString)),
+			Md5:    sampleMd5,
+			Sha256: sampleSha256,
+		},
+	}
+	testCases["verifiedMd5"] = TestData{
+		Input: gointelowl.SampleDownloadOptions{
+			VerifyMd5: true,
+		},
+		Data:       sampleString,
+		StatusCode: http.StatusOK,
+		Want: &gointelowl.SampleDownloadResult{
+			Size:   int64(len(sampleString)),
+			Md5:    sampleMd5,
+			Sha256: sampleSha256,
+		},
+	}
+	testCases["md5Mismatch"] = TestData{
+		Input: gointelowl.SampleDownloadOptions{
+			VerifyMd5: true,
+		},
+		Data:       "This is another sample",
+		StatusCode: http.StatusOK,
+		Want:       gointelowl.ErrSampleMd5Mismatch,
+	}
+	testCases["tooLarge"] = TestData{
+		Input: gointelowl.SampleDownloadOptions{
+			MaxSize: 4,
+		},
+		Data:       sampleString,
+		StatusCode: http.StatusOK,
+		Want:       gointelowl.ErrSampleTooLarge,
+	}
+	testCases["exactMaxSize"] = TestData{
+		Input: gointelowl.SampleDownloadOptions{
+			MaxSize: int64(len(sampleString)),
+		},
+		Data:       sampleString,
+		StatusCode: http.StatusOK,
+		Want: &gointelowl.SampleDownloadResult{
+			Size:   int64(len(sampleString)),
+			Md5:    sampleMd5,
+			Sha256: sampleSha256,
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			jobId := uint64(1)
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, jobId), serverHandler(t, TestData{
+				Data:       fmt.Sprintf(`{"id":1,"md5":"%s","file_name":"sample.txt","status":"reported_without_fails"}`, sampleMd5),
+				StatusCode: http.StatusOK,
+			}, "GET"))
+			apiHandler.Handle(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, jobId), serverHandler(t, testCase, "GET"))
+			options, ok := testCase.Input.(gointelowl.SampleDownloadOptions)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			var sample bytes.Buffer
+			result, err := client.JobService.DownloadSampleTo(ctx, jobId, &sample, &options)
+			if wantError, isError := testCase.Want.(error); isError {
+				if !errors.Is(err, wantError) {
+					t.Fatalf("Expected %v, got %v", wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, result)
+			testWantData(t, sampleString, sample.String())
+		})
+	}
+}
+
+func TestJobServiceDownloadSampleToWithoutMd5(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	ctx := context.Background()
+	jobId := uint64(1)
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, jobId), serverHandler(t, TestData{
+		Data:       `{"id":1,"md5":"","observable_name":"8.8.8.8","status":"reported_without_fails"}`,
+		StatusCode: http.StatusOK,
+	}, "GET"))
+	downloaded := false
+	apiHandler.HandleFunc(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, jobId), func(w http.ResponseWriter, r *http.Request) {
+		downloaded = true
+	})
+	var sample bytes.Buffer
+	_, err := client.JobService.DownloadSampleTo(ctx, jobId, &sample, &gointelowl.SampleDownloadOptions{VerifyMd5: true})
+	if !errors.Is(err, gointelowl.ErrSampleNoMd5) {
+		t.Fatalf("Expected %v, got %v", gointelowl.ErrSampleNoMd5, err)
+	}
+	// * nothing is downloaded when there is nothing to verify it against
+	testWantData(t, false, downloaded)
+}
+
+func TestJobServiceDownloadSampleToZip(t *testing.T) {
+	sampleString := "This is the sample"
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	ctx := context.Background()
+	jobId := uint64(1)
+	apiHandler.Handle(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, jobId), serverHandler(t, TestData{
+		Data:       sampleString,
+		StatusCode: http.StatusOK,
+	}, "GET"))
+	filePath := filepath.Join(t.TempDir(), "sample.zip")
+	result, err := client.JobService.DownloadSampleToFile(ctx, jobId, filePath, &gointelowl.SampleDownloadOptions{
+		Zip:          true,
+		ZipEntryName: "sample.txt",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, int64(len(sampleString)), result.Size)
+
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatalf("Could not open the zip archive: %v", err)
+	}
+	defer archive.Close()
+	if len(archive.File) != 1 {
+		t.Fatalf("Expected a single entry, got %d", len(archive.File))
+	}
+	entry := archive.File[0]
+	testWantData(t, "sample.txt", entry.Name)
+	testWantData(t, uint64(len(sampleString)), entry.UncompressedSize64)
+	if entry.Flags&0x1 == 0 {
+		t.Fatalf("The entry is not encrypted")
+	}
+	rawEntry, err := entry.OpenRaw()
+	if err != nil {
+		t.Fatalf("Could not open the zip entry: %v", err)
+	}
+	rawBytes, _ := io.ReadAll(rawEntry)
+	if bytes.Contains(rawBytes, []byte(sampleString)) {
+		t.Fatalf("The sample is stored in clear")
+	}
+	// * the entry opens with the conventional password and gives the sample back
+	testWantData(t, uint16(zip.Deflate), entry.Method)
+	plainBytes := decryptZipCrypto(t, rawBytes, "infected", byte(entry.ModifiedTime>>8))
+	sample, err := io.ReadAll(flate.NewReader(bytes.NewReader(plainBytes)))
+	if err != nil {
+		t.Fatalf("Could not inflate the zip entry: %v", err)
+	}
+	testWantData(t, sampleString, string(sample))
+	testWantData(t, entry.CRC32, crc32.ChecksumIEEE(sample))
+}
+
+// Helper test
+// Decrypting a ZipCrypto entry, checking the password against the last byte of its 12 bytes encryption header
+func decryptZipCrypto(t *testing.T, encrypted []byte, password string, check byte) []byte {
+	t.Helper()
+	keys := [3]uint32{0x12345678, 0x23456789, 0x34567890}
+	crc32Update := func(crc uint32, data byte) uint32 {
+		return crc32.IEEETable[byte(crc)^data] ^ (crc >> 8)
+	}
+	updateKeys := func(plainByte byte) {
+		keys[0] = crc32Update(keys[0], plainByte)
+		keys[1] = (keys[1]+(keys[0]&0xff))*134775813 + 1
+		keys[2] = crc32Update(keys[2], byte(keys[1]>>24))
+	}
+	for _, passwordByte := range []byte(password) {
+		updateKeys(passwordByte)
+	}
+	if len(encrypted) < 12 {
+		t.Fatalf("The zip entry has no encryption header")
+	}
+	plain := make([]byte, len(encrypted))
+	for index, encryptedByte := range encrypted {
+		temp := uint16(keys[2] | 2)
+		plain[index] = encryptedByte ^ byte((temp*(temp^1))>>8)
+		updateKeys(plain[index])
+	}
+	if plain[11] != check {
+		t.Fatalf("Wrong password for the zip entry")
+	}
+	return plain[12:]
+}
+
 func TestJobServiceDelete(t *testing.T) {
 	// *table test case
 	testCases := make(map[string]TestData)
@@ -154,6 +358,7 @@ func TestJobServiceDelete(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusNotFound,
 			Message:    notFoundJson,
+			Detail:     "Not found.",
 		},
 	}
 	for name, testCase := range testCases {
@@ -194,6 +399,7 @@ func TestJobServiceKill(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusNotFound,
 			Message:    `{"detail":"Not found."}`,
+			Detail:     "Not found.",
 		},
 	}
 	testCases["jobNotRunning"] = TestData{
@@ -203,6 +409,7 @@ func TestJobServiceKill(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"errors":{"detail":"Job is not running"}}`,
+			Detail:     "Job is not running",
 		},
 	}
 	for name, testCase := range testCases {
@@ -254,6 +461,9 @@ func TestJobServiceKillAnalyzer(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusNotFound,
 			Message:    `{"errors":{"analyzer report":"Not found."}}`,
+			FieldErrors: map[string][]string{
+				"analyzer report": {"Not found."},
+			},
 		},
 	}
 	testCases["analyzerNotRunning"] = TestData{
@@ -266,6 +476,7 @@ func TestJobServiceKillAnalyzer(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"errors":{"detail":"Plugin call is not running or pending"}}`,
+			Detail:     "Plugin call is not running or pending",
 		},
 	}
 	for name, testCase := range testCases {
@@ -387,3 +598,170 @@ func TestJobServiceRetryConnector(t *testing.T) {
 		})
 	}
 }
+
+func TestJobServiceWait(t *testing.T) {
+	runningJobJson := `{"id":80,"status":"running","analyzer_reports":[{"name":"Classic_DNS","status":"SUCCESS","report":{},"errors":[],"type":"analyzer"},{"name":"Phishstats","status":"RUNNING","report":{},"errors":[],"type":"analyzer"}],"connector_reports":[]}`
+	finishedJobJson := `{"id":80,"status":"reported_without_fails","analyzer_reports":[{"name":"Classic_DNS","status":"SUCCESS","report":{},"errors":[],"type":"analyzer"},{"name":"Phishstats","status":"SUCCESS","report":{},"errors":[],"type":"analyzer"}],"connector_reports":[]}`
+	finishedJob := gointelowl.Job{}
+	if unmarshalError := json.Unmarshal([]byte(finishedJobJson), &finishedJob); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	waitOptions := &gointelowl.WaitOptions{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+	}
+
+	t.Run("simple", func(t *testing.T) {
+		client, apiHandler, closeServer := setup()
+		defer closeServer()
+		ctx := context.Background()
+		polls := 0
+		apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 80), func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "GET")
+			polls++
+			if polls < 3 {
+				fmt.Fprint(w, runningJobJson)
+			} else {
+				fmt.Fprint(w, finishedJobJson)
+			}
+		})
+		finishedReports := []string{}
+		options := *waitOptions
+		options.OnPoll = func(job *gointelowl.Job, reports []gointelowl.Report) {
+			for _, report := range reports {
+				finishedReports = append(finishedReports, report.Name)
+			}
+		}
+		gottenJob, err := client.JobService.Wait(ctx, 80, &options)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		testWantData(t, &finishedJob, gottenJob)
+		testWantData(t, 3, polls)
+		testWantData(t, []string{"Classic_DNS", "Phishstats"}, finishedReports)
+	})
+
+	t.Run("noJitter", func(t *testing.T) {
+		client, apiHandler, closeServer := setup()
+		defer closeServer()
+		ctx := context.Background()
+		interval := 10 * time.Millisecond
+		polls := 0
+		lastPoll := time.Now()
+		apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 80), func(w http.ResponseWriter, r *http.Request) {
+			polls++
+			// * without jitter no delay is shorter than the interval
+			if polls > 1 && time.Since(lastPoll) < interval {
+				t.Errorf("Polled after %s, want at least %s", time.Since(lastPoll), interval)
+			}
+			lastPoll = time.Now()
+			if polls < 10 {
+				fmt.Fprint(w, runningJobJson)
+			} else {
+				fmt.Fprint(w, finishedJobJson)
+			}
+		})
+		_, err := client.JobService.Wait(ctx, 80, &gointelowl.WaitOptions{
+			InitialInterval: interval,
+			MaxInterval:     interval,
+			Multiplier:      1,
+			Jitter:          -1,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		testWantData(t, 10, polls)
+	})
+
+	t.Run("contextCancelled", func(t *testing.T) {
+		client, apiHandler, closeServer := setup()
+		defer closeServer()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 80), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, runningJobJson)
+		})
+		_, err := client.JobService.Wait(ctx, 80, waitOptions)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected context.DeadlineExceeded, got: %v", err)
+		}
+	})
+}
+
+func TestJobServiceListWithOptions(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	after := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		want := "md5=40ff44d9e619b17524bf3763204f9cbb&observable_classification=ip&ordering=-received_request_time&page=2&page_size=10&received_request_time__gte=2022-07-01T00%3A00%3A00Z&status=failed&tags=malware%2Cphishing&tlp=AMBER"
+		testWantData(t, want, r.URL.RawQuery)
+		fmt.Fprint(w, `{"count":11,"total_pages":2,"results":[{"id":1}]}`)
+	})
+	ctx := context.Background()
+	jobListResponse, err := client.JobService.ListWithOptions(ctx, &gointelowl.JobListOptions{
+		Page:                     2,
+		PageSize:                 10,
+		Status:                   "failed",
+		Tlp:                      gointelowl.AMBER,
+		ObservableClassification: "ip",
+		Md5:                      "40ff44d9e619b17524bf3763204f9cbb",
+		Tags:                     []string{"malware", "phishing"},
+		ReceivedRequestTimeAfter: &after,
+		Ordering:                 "-received_request_time",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	testWantData(t, 11, jobListResponse.Count)
+}
+
+func TestJobServiceListAll(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"count":5,"total_pages":3,"results":[{"id":5},{"id":4}]}`,
+		"2": `{"count":5,"total_pages":3,"results":[{"id":3},{"id":2}]}`,
+		"3": `{"count":5,"total_pages":3,"results":[{"id":1}]}`,
+	}
+	t.Run("simple", func(t *testing.T) {
+		client, apiHandler, closeServer := setup()
+		defer closeServer()
+		requestedPages := []string{}
+		apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+			page := r.URL.Query().Get("page")
+			requestedPages = append(requestedPages, page)
+			fmt.Fprint(w, pages[page])
+		})
+		ctx := context.Background()
+		jobs, err := client.JobService.ListAll(ctx, &gointelowl.JobListOptions{PageSize: 2})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		jobIds := []int{}
+		for _, job := range jobs {
+			jobIds = append(jobIds, job.ID)
+		}
+		testWantData(t, []int{5, 4, 3, 2, 1}, jobIds)
+		testWantData(t, []string{"1", "2", "3"}, requestedPages)
+	})
+	t.Run("contextCancelled", func(t *testing.T) {
+		client, apiHandler, closeServer := setup()
+		defer closeServer()
+		apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, pages[r.URL.Query().Get("page")])
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		iterator := client.JobService.Iterate(nil)
+		visited := 0
+		for iterator.Next(ctx) {
+			visited++
+			if visited == 3 {
+				cancel()
+			}
+		}
+		testWantData(t, 3, visited)
+		if !errors.Is(iterator.Err(), context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got: %v", iterator.Err())
+		}
+	})
+}
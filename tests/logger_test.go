@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/intelowlproject/go-intelowl/constants"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+// testLogger records every log entry
+type testLogger struct {
+	mutex   sync.Mutex
+	entries []gointelowl.LogFields
+}
+
+func (logger *testLogger) Debug(message string, fields gointelowl.LogFields) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.entries = append(logger.entries, fields)
+}
+
+func TestRequestTracing(t *testing.T) {
+	apiHandler := http.NewServeMux()
+	testServer := httptest.NewServer(apiHandler)
+	defer testServer.Close()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		// * echoing the token back to make sure it is redacted from the response body as well
+		fmt.Fprintf(w, `{"id":1,"label":"%s","color":"#ffffff"}`, strings.Repeat("a", 100)+"secret-token")
+	})
+	logger := &testLogger{}
+	client := gointelowl.NewIntelOwlClient(
+		&gointelowl.IntelOwlClientOptions{
+			Url:   testServer.URL,
+			Token: "secret-token",
+		},
+		nil,
+		&gointelowl.LoggerParams{
+			Logger:      logger,
+			DumpBodies:  true,
+			MaxDumpSize: 20,
+		},
+	)
+	ctx := context.Background()
+	_, err := client.TagService.Create(ctx, &gointelowl.TagParams{Label: "secret-token", Color: "#ffffff"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	testWantData(t, 1, len(logger.entries))
+	entry := logger.entries[0]
+	testWantData(t, "TagService.Create", entry["operation"])
+	testWantData(t, "POST", entry["method"])
+	testWantData(t, testServer.URL+constants.BASE_TAG_URL, entry["url"])
+	testWantData(t, http.StatusOK, entry["status"])
+	testWantData(t, 1, entry["attempt"])
+	testWantData(t, int64(len(`{"label":"secret-token","color":"#ffffff"}`)), entry["request_size"])
+	testWantData(t, `{"label":"[REDACTED]...(truncated)`, entry["request_body"])
+	testWantData(t, `{"id":1,"label":"aaa...(truncated)`, entry["response_body"])
+	headers, _ := entry["request_headers"].(map[string]string)
+	testWantData(t, "[REDACTED]", headers["Authorization"])
+	for _, value := range entry {
+		if strings.Contains(fmt.Sprint(value), "secret-token") {
+			t.Fatalf("The token was logged: %v", entry)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/intelowlproject/go-intelowl/constants"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+func TestMiddlewares(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		testWantData(t, "go-intelowl-test", r.Header.Get("User-Agent"))
+		testWantData(t, 32, len(r.Header.Get("X-Request-ID")))
+		fmt.Fprint(w, `[]`)
+	})
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_TAG_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"detail":"Not found."}`)
+	})
+	order := []string{}
+	timings := map[string]error{}
+	tracing := func(name string) gointelowl.Middleware {
+		return func(next gointelowl.Handler) gointelowl.Handler {
+			return func(ctx context.Context, call *gointelowl.Call) (*gointelowl.SuccessResponse, error) {
+				order = append(order, name+" before "+call.Operation)
+				sucessResp, err := next(ctx, call)
+				order = append(order, name+" after "+call.Operation)
+				return sucessResp, err
+			}
+		}
+	}
+	client.Use(
+		tracing("first"),
+		tracing("second"),
+		gointelowl.UserAgentMiddleware("go-intelowl-test"),
+		gointelowl.RequestIDMiddleware(""),
+		gointelowl.TimingMiddleware(func(operation string, duration time.Duration, err error) {
+			timings[operation] = err
+		}),
+	)
+	ctx := context.Background()
+	if _, err := client.TagService.List(ctx); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	testWantData(t, []string{
+		"first before TagService.List",
+		"second before TagService.List",
+		"second after TagService.List",
+		"first after TagService.List",
+	}, order)
+
+	// * the middlewares see the IntelOwlError
+	_, err := client.TagService.Get(ctx, 1)
+	if !gointelowl.IsNotFound(timings["TagService.Get"]) || !gointelowl.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, got: %v", timings["TagService.Get"])
+	}
+	testWantData(t, true, timings["TagService.List"] == nil)
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("The request should not reach the server")
+	})
+	errBlocked := errors.New("blocked")
+	client.Use(func(next gointelowl.Handler) gointelowl.Handler {
+		return func(ctx context.Context, call *gointelowl.Call) (*gointelowl.SuccessResponse, error) {
+			switch call.Operation {
+			case "TagService.List":
+				return &gointelowl.SuccessResponse{
+					StatusCode: http.StatusOK,
+					Data:       []byte(`[{"id":1,"label":"MOCKED","color":"#ffffff"}]`),
+				}, nil
+			case "TagService.Delete":
+				return nil, errBlocked
+			}
+			return next(ctx, call)
+		}
+	})
+	ctx := context.Background()
+	tags, err := client.TagService.List(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	testWantData(t, []gointelowl.Tag{{ID: 1, Label: "MOCKED", Color: "#ffffff"}}, *tags)
+	_, err = client.TagService.Delete(ctx, 1)
+	testWantData(t, true, errors.Is(err, errBlocked))
+}
+
+func TestMiddlewareJobListOperations(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"count":0,"total_pages":1,"results":[]}`)
+	})
+	operations := []string{}
+	client.Use(func(next gointelowl.Handler) gointelowl.Handler {
+		return func(ctx context.Context, call *gointelowl.Call) (*gointelowl.SuccessResponse, error) {
+			operations = append(operations, call.Operation)
+			return next(ctx, call)
+		}
+	})
+	ctx := context.Background()
+	if _, err := client.JobService.List(ctx); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err := client.JobService.ListWithOptions(ctx, &gointelowl.JobListOptions{Page: 2}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// * List is traced as itself even though it shares the request of ListWithOptions
+	testWantData(t, []string{"JobService.List", "JobService.ListWithOptions"}, operations)
+}
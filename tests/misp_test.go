@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+	"github.com/intelowlproject/go-intelowl/misp"
+)
+
+func TestMispExportObservable(t *testing.T) {
+	job := loadStixJob(t)
+	job.ObservableName = "8.8.8.8"
+	job.ObservableClassification = gointelowl.IPClassification
+	job.Tlp = "AMBER"
+	event, err := misp.Export(job)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	compareWithGoldenFile(t, misp.EventDocument{Event: event}, "misp", "observable.json")
+
+	eventJson, err := event.JSON()
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	eventDocument := misp.EventDocument{}
+	if err := json.Unmarshal(eventJson, &eventDocument); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if diff := cmp.Diff(event, eventDocument.Event); diff != "" {
+		t.Fatalf(diff)
+	}
+}
+
+func TestMispExportFile(t *testing.T) {
+	job := loadStixJob(t)
+	job.IsSample = true
+	job.FileName = "invoice.pdf"
+	job.FileMimetype = "application/pdf"
+	job.Md5 = "a5b6f1c4b0d2e3f4a5b6c7d8e9f0a1b2"
+	job.Tlp = "RED"
+	exporter := misp.NewExporter(&misp.ExporterOptions{VerdictMode: misp.VerdictsAsTags, Distribution: misp.DistributionCommunity})
+	event, err := exporter.Export(job)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	compareWithGoldenFile(t, misp.EventDocument{Event: event}, "misp", "file.json")
+}
+
+func TestMispExportFilesWithoutHash(t *testing.T) {
+	invoiceJob := loadStixJob(t)
+	invoiceJob.IsSample = true
+	invoiceJob.FileName = "invoice.pdf"
+	invoiceJob.AnalyzerReports = nil
+	receiptJob := loadStixJob(t)
+	receiptJob.ID = 43
+	receiptJob.IsSample = true
+	receiptJob.FileName = "receipt.pdf"
+	receiptJob.AnalyzerReports = nil
+	unnamedJob := loadStixJob(t)
+	unnamedJob.ID = 44
+	unnamedJob.IsSample = true
+	unnamedJob.AnalyzerReports = nil
+	event, err := misp.Export(invoiceJob, receiptJob, unnamedJob)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	// * without a MD5, the files are told apart by their name or their job
+	if len(event.Objects) != 3 {
+		t.Fatalf("Got %d objects, want one file object per job", len(event.Objects))
+	}
+}
+
+func TestMispExportGroup(t *testing.T) {
+	ipJob := loadStixJob(t)
+	ipJob.ObservableName = "8.8.8.8"
+	ipJob.ObservableClassification = gointelowl.IPClassification
+	ipJob.Tlp = "GREEN"
+	domainJob := loadStixJob(t)
+	domainJob.ID = 43
+	domainJob.ObservableName = "evil.com"
+	domainJob.Tlp = "AMBER"
+	domainJob.Tags = []gointelowl.Tag{{ID: 3, Label: "phishing", Color: "#0000ff"}}
+	otherIPJob := loadStixJob(t)
+	otherIPJob.ID = 44
+	otherIPJob.ObservableName = "8.8.8.8"
+	otherIPJob.Tlp = ""
+
+	summarizer := gointelowl.NewSummarizer()
+	summarizer.Register("Yara", func(report *gointelowl.Report) (float64, error) {
+		return 0.9, nil
+	})
+	exporter := misp.NewExporter(&misp.ExporterOptions{Summarizer: summarizer})
+	event, err := exporter.Export(ipJob, domainJob, otherIPJob)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	compareWithGoldenFile(t, misp.EventDocument{Event: event}, "misp", "group.json")
+
+	// * the most restrictive TLP and the worst verdict of the jobs
+	if event.Tags[0].Name != "tlp:amber" || event.ThreatLevelID != misp.ThreatLevelHigh {
+		t.Fatalf("Got %s and threat level %s, want tlp:amber and a high threat level", event.Tags[0].Name, event.ThreatLevelID)
+	}
+	// * the same observable is exported once
+	if attributes := event.AttributesOfType("ip-dst"); len(attributes) != 1 || !attributes[0].ToIDS {
+		t.Fatalf("Got %v, want one ip-dst attribute to detect", attributes)
+	}
+
+	if _, err := exporter.Export(); err == nil {
+		t.Fatalf("Expected an error without jobs")
+	}
+	otherIPJob.Tlp = "PURPLE"
+	if _, err := exporter.Export(ipJob, otherIPJob); err == nil {
+		t.Fatalf("Expected an error for an unknown TLP")
+	}
+}
+
+func TestMispAttributeTypes(t *testing.T) {
+	// * table test case
+	testCases := map[string]TestData{
+		"ipv4":    {Input: "8.8.8.8", Want: "ip-dst"},
+		"ipv6":    {Input: "2001:4860:4860::8888", Want: "ip-dst"},
+		"domain":  {Input: "evil.com", Want: "domain"},
+		"url":     {Input: "https://evil.com/login", Want: "url"},
+		"md5":     {Input: "a5b6f1c4b0d2e3f4a5b6c7d8e9f0a1b2", Want: "md5"},
+		"sha256":  {Input: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", Want: "sha256"},
+		"email":   {Input: "attacker@evil.com", Want: "email"},
+		"generic": {Input: "Windows_Trojan_Generic", Want: "text"},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			observableName, ok := testCase.Input.(string)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			job := &gointelowl.Job{}
+			job.ID = 1
+			job.ObservableName = observableName
+			event, err := misp.Export(job)
+			if err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			testWantData(t, testCase.Want, event.Attributes[0].Type)
+		})
+	}
+}
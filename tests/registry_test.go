@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/intelowlproject/go-intelowl/constants"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+// Helper test
+// Serving the analyzer and connector configurations in testFiles, counting how many times they are fetched
+func servePluginConfigs(t *testing.T, apiHandler *http.ServeMux) *int {
+	t.Helper()
+	analyzerConfigsJson, _ := os.ReadFile(path.Join("./testFiles/", "analyzerConfigs.json"))
+	connectorConfigsJson, _ := os.ReadFile(path.Join("./testFiles/", "connectorConfigs.json"))
+	fetches := 0
+	apiHandler.Handle(constants.ANALYZER_CONFIG_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fetches++
+		_, _ = w.Write(analyzerConfigsJson)
+	}))
+	apiHandler.Handle(constants.CONNECTOR_CONFIG_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		_, _ = w.Write(connectorConfigsJson)
+	}))
+	return &fetches
+}
+
+func TestPluginRegistryCache(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	fetches := servePluginConfigs(t, apiHandler)
+	ctx := context.Background()
+	pluginRegistry := client.NewPluginRegistry(gointelowl.DefaultPluginRegistryTTL)
+
+	if !pluginRegistry.FetchedAt().IsZero() {
+		t.Fatalf("The configurations should be fetched on first use")
+	}
+	analyzerConfig, err := pluginRegistry.Analyzer(ctx, "Classic_DNS")
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if analyzerConfig.Name != "Classic_DNS" {
+		t.Fatalf("Got %s, want Classic_DNS", analyzerConfig.Name)
+	}
+	connectorConfig, err := pluginRegistry.Connector(ctx, "MISP")
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if connectorConfig.MaximumTlp != gointelowl.AMBER {
+		t.Fatalf("Got %s, want AMBER", connectorConfig.MaximumTlp)
+	}
+	if _, err := pluginRegistry.Analyzer(ctx, "Nope"); !errors.Is(err, gointelowl.ErrPluginNotFound) {
+		t.Fatalf("Error: %v", err)
+	}
+	if _, err := pluginRegistry.Connector(ctx, "Nope"); !errors.Is(err, gointelowl.ErrPluginNotFound) {
+		t.Fatalf("Error: %v", err)
+	}
+	if *fetches != 1 {
+		t.Fatalf("Fetched %d times, want 1", *fetches)
+	}
+
+	if err := pluginRegistry.Refresh(ctx); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if *fetches != 2 {
+		t.Fatalf("Fetched %d times after Refresh, want 2", *fetches)
+	}
+
+	pluginRegistry.TTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, err := pluginRegistry.Analyzers(ctx, nil); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if *fetches != 3 {
+		t.Fatalf("Fetched %d times after expiring, want 3", *fetches)
+	}
+}
+
+func TestPluginRegistryRefreshError(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.ANALYZER_CONFIG_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	pluginRegistry := client.NewPluginRegistry(0)
+	if _, err := pluginRegistry.Analyzer(context.Background(), "Classic_DNS"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if !pluginRegistry.FetchedAt().IsZero() {
+		t.Fatalf("A failed refresh should not be cached")
+	}
+}
+
+func TestPluginRegistryAnalyzers(t *testing.T) {
+	testCases := make(map[string]TestData)
+	testCases["all"] = TestData{
+		Input: (*gointelowl.AnalyzerQuery)(nil),
+		Want:  []string{"Classic_DNS", "File_Info", "PDF_Info", "Shodan_Honeyscore", "Strings_Info", "VirusTotal_v3_Get_Observable"},
+	}
+	testCases["observableType"] = TestData{
+		Input: &gointelowl.AnalyzerQuery{ObservableType: "ip"},
+		Want:  []string{"Classic_DNS", "Shodan_Honeyscore", "VirusTotal_v3_Get_Observable"},
+	}
+	testCases["mimetype"] = TestData{
+		Input: &gointelowl.AnalyzerQuery{Mimetype: "application/pdf"},
+		Want:  []string{"File_Info", "PDF_Info"},
+	}
+	testCases["noExternalService"] = TestData{
+		Input: &gointelowl.AnalyzerQuery{ExternalService: gointelowl.Bool(false)},
+		Want:  []string{"File_Info", "PDF_Info", "Strings_Info"},
+	}
+	testCases["dockerBased"] = TestData{
+		Input: &gointelowl.AnalyzerQuery{DockerBased: gointelowl.Bool(true)},
+		Want:  []string{"Strings_Info"},
+	}
+	testCases["noLeaksConfiguredEnabled"] = TestData{
+		Input: &gointelowl.AnalyzerQuery{ObservableType: "ip", LeaksInfo: gointelowl.Bool(false), ConfiguredOnly: true, EnabledOnly: true},
+		Want:  []string{"Classic_DNS"},
+	}
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	servePluginConfigs(t, apiHandler)
+	pluginRegistry := client.NewPluginRegistry(gointelowl.DefaultPluginRegistryTTL)
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			analyzerConfigs, err := pluginRegistry.Analyzers(context.Background(), testCase.Input.(*gointelowl.AnalyzerQuery))
+			if err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			if diff := cmp.Diff(testCase.Want, gointelowl.AnalyzerNames(analyzerConfigs)); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func TestPluginRegistryConnectors(t *testing.T) {
+	testCases := make(map[string]TestData)
+	testCases["all"] = TestData{
+		Input: (*gointelowl.ConnectorQuery)(nil),
+		Want:  []string{"MISP", "OpenCTI", "YETI"},
+	}
+	testCases["acceptsTlp"] = TestData{
+		Input: &gointelowl.ConnectorQuery{AcceptsTlp: gointelowl.GREEN},
+		Want:  []string{"MISP", "YETI"},
+	}
+	testCases["configuredOnly"] = TestData{
+		Input: &gointelowl.ConnectorQuery{AcceptsTlp: gointelowl.GREEN, ConfiguredOnly: true},
+		Want:  []string{"MISP"},
+	}
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	servePluginConfigs(t, apiHandler)
+	pluginRegistry := client.NewPluginRegistry(gointelowl.DefaultPluginRegistryTTL)
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			connectorConfigs, err := pluginRegistry.Connectors(context.Background(), testCase.Input.(*gointelowl.ConnectorQuery))
+			if err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			if diff := cmp.Diff(testCase.Want, gointelowl.ConnectorNames(connectorConfigs)); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
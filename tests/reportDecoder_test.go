@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+// Helper test
+// Loading the analyzer reports in testFiles by analyzer name
+func loadAnalyzerReports(t *testing.T) map[string]gointelowl.Report {
+	t.Helper()
+	analyzerReportsJson, _ := os.ReadFile(path.Join("./testFiles/", "analyzerReports.json"))
+	analyzerReports := []gointelowl.Report{}
+	if unmarshalError := json.Unmarshal(analyzerReportsJson, &analyzerReports); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	reportsByName := map[string]gointelowl.Report{}
+	for _, report := range analyzerReports {
+		reportsByName[report.Name] = report
+	}
+	return reportsByName
+}
+
+func TestReportDecode(t *testing.T) {
+	reports := loadAnalyzerReports(t)
+	report := reports["VirusTotal_v3_Get_Observable"]
+	virusTotalReport := gointelowl.VirusTotalReport{}
+	if err := report.Decode(&virusTotalReport); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if virusTotalReport.Data.Attributes.LastAnalysisStats.Malicious != 1 || virusTotalReport.Data.Attributes.Asn != 15169 {
+		t.Fatalf("Wrong report: %+v", virusTotalReport)
+	}
+	if diff := cmp.Diff("malicious", virusTotalReport.Data.Attributes.LastAnalysisResults["CRDF"].Category); diff != "" {
+		t.Fatalf(diff)
+	}
+
+	wrongReport := gointelowl.Report{Name: "Broken", Report: map[string]interface{}{"honeyscore": "high"}}
+	if err := wrongReport.Decode(&gointelowl.ShodanHoneyscoreReport{}); err == nil {
+		t.Fatalf("Expected an error decoding a string into a float")
+	}
+}
+
+func TestReportDecodeKnown(t *testing.T) {
+	reports := loadAnalyzerReports(t)
+	testCases := make(map[string]TestData)
+	testCases["VirusTotal_v3_Get_Observable"] = TestData{
+		Want: func(decoded interface{}) bool {
+			virusTotalReport, ok := decoded.(*gointelowl.VirusTotalReport)
+			return ok && virusTotalReport.Link == "https://www.virustotal.com/gui/ip-address/8.8.8.8"
+		},
+	}
+	testCases["AbuseIPDB"] = TestData{
+		Want: func(decoded interface{}) bool {
+			abuseIPDBReport, ok := decoded.(*gointelowl.AbuseIPDBReport)
+			return ok && abuseIPDBReport.Data.IsWhitelisted && abuseIPDBReport.Data.Reports[0].Categories[0] == 4 && abuseIPDBReport.CategoriesFound["DDoS Attack"] == 1
+		},
+	}
+	testCases["Shodan_Honeyscore"] = TestData{
+		Want: func(decoded interface{}) bool {
+			honeyscoreReport, ok := decoded.(*gointelowl.ShodanHoneyscoreReport)
+			return ok && honeyscoreReport.Honeyscore == 0.3
+		},
+	}
+	testCases["Yara"] = TestData{
+		Want: func(decoded interface{}) bool {
+			yaraReport, ok := decoded.(*gointelowl.YaraReport)
+			return ok && len(yaraReport.Matches()) == 1 && yaraReport.Matches()[0].Match == "Windows_Trojan_Generic"
+		},
+	}
+	testCases["File_Info"] = TestData{
+		Want: func(decoded interface{}) bool {
+			fileInfoReport, ok := decoded.(*gointelowl.FileInfoReport)
+			return ok && fileInfoReport.Mimetype == "application/pdf" && fileInfoReport.Exiftool["PageCount"] == 2.0
+		},
+	}
+	testCases["Strings_Info"] = TestData{
+		Want: func(decoded interface{}) bool {
+			stringsInfoReport, ok := decoded.(*gointelowl.StringsInfoReport)
+			return ok && len(stringsInfoReport.Data) == 2 && stringsInfoReport.Uris[0] == "http://evil.com/payload"
+		},
+	}
+	testCases["MaxMindGeoIP"] = TestData{
+		Want: func(decoded interface{}) bool {
+			maxMindReport, ok := decoded.(*gointelowl.MaxMindReport)
+			return ok && maxMindReport.Country.IsoCode == "US" && maxMindReport.City.Name() == "Mountain View" && maxMindReport.Location.Latitude == 37.386
+		},
+	}
+	testCases["OTXQuery"] = TestData{
+		Want: func(decoded interface{}) bool {
+			otxReport, ok := decoded.(*gointelowl.OTXReport)
+			return ok && otxReport.Pulses[0].Name == "DNS resolvers abused" && otxReport.PassiveDNS[0]["hostname"] == "dns.google"
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			report := reports[name]
+			decoded, err := report.DecodeKnown()
+			if err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			if !testCase.Want.(func(interface{}) bool)(decoded) {
+				t.Fatalf("Wrong report: %+v", decoded)
+			}
+		})
+	}
+}
+
+type customAnalyzerReport struct {
+	Verdict string `json:"verdict"`
+}
+
+func TestRegisterReportDecoder(t *testing.T) {
+	report := gointelowl.Report{Name: "Custom_Analyzer", Report: map[string]interface{}{"verdict": "malicious"}}
+	if _, err := report.DecodeKnown(); !errors.Is(err, gointelowl.ErrNoReportDecoder) {
+		t.Fatalf("Error: %v", err)
+	}
+
+	reportDecoderRegistry := gointelowl.NewReportDecoderRegistry()
+	reportDecoderRegistry.Register("Custom_Analyzer", gointelowl.NewStructReportDecoder(func() interface{} {
+		return &customAnalyzerReport{}
+	}))
+	decoded, err := reportDecoderRegistry.Decode(&report)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if diff := cmp.Diff(&customAnalyzerReport{Verdict: "malicious"}, decoded); diff != "" {
+		t.Fatalf(diff)
+	}
+	// * the default registry is left alone
+	if _, ok := gointelowl.DefaultReportDecoderRegistry.Lookup("Custom_Analyzer"); ok {
+		t.Fatalf("Registering in a registry should not touch the default one")
+	}
+	if len(reportDecoderRegistry.AnalyzerNames()) != len(gointelowl.DefaultReportDecoderRegistry.AnalyzerNames())+1 {
+		t.Fatalf("Wrong analyzer names: %v", reportDecoderRegistry.AnalyzerNames())
+	}
+
+	gointelowl.RegisterReportDecoder("Custom_Analyzer_Default", gointelowl.NewStructReportDecoder(func() interface{} {
+		return &customAnalyzerReport{}
+	}))
+	report.Name = "Custom_Analyzer_Default"
+	if decoded, err := report.DecodeKnown(); err != nil || decoded.(*customAnalyzerReport).Verdict != "malicious" {
+		t.Fatalf("Got %+v, %v", decoded, err)
+	}
+}
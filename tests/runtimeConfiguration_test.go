@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+func TestParameterValues(t *testing.T) {
+	analyzerConfigs, _ := loadPluginConfigs(t)
+	params := map[string]map[string]gointelowl.Parameter{}
+	for _, analyzerConfig := range analyzerConfigs {
+		params[analyzerConfig.Name] = analyzerConfig.Params
+	}
+
+	queryType := params["Classic_DNS"]["query_type"]
+	if value, err := queryType.StringValue(); err != nil || value != "A" {
+		t.Fatalf("Got %q, %v", value, err)
+	}
+	if _, err := queryType.IntValue(); err == nil || err.Error() != "The parameter of type str holds a string, not a value of type int" {
+		t.Fatalf("Error: %v", err)
+	}
+	maxNumberOfStrings := params["Strings_Info"]["max_number_of_strings"]
+	if value, err := maxNumberOfStrings.IntValue(); err != nil || value != 500 {
+		t.Fatalf("Got %d, %v", value, err)
+	}
+	if value, err := maxNumberOfStrings.FloatValue(); err != nil || value != 500 {
+		t.Fatalf("Got %f, %v", value, err)
+	}
+	rankStrings := params["Strings_Info"]["rank_strings"]
+	if value, err := rankStrings.BoolValue(); err != nil || value {
+		t.Fatalf("Got %t, %v", value, err)
+	}
+	relationships := params["VirusTotal_v3_Get_Observable"]["relationships_to_request"]
+	if value, err := relationships.StringListValue(); err != nil || len(value) != 0 {
+		t.Fatalf("Got %v, %v", value, err)
+	}
+	if _, err := relationships.DictValue(); err == nil {
+		t.Fatalf("A list is not a dict")
+	}
+	dict := gointelowl.Parameter{Value: map[string]interface{}{"a": 1.0}, Type: "dict"}
+	if value, err := dict.DictValue(); err != nil || value["a"] != 1.0 {
+		t.Fatalf("Got %v, %v", value, err)
+	}
+}
+
+func TestRuntimeConfigurationBuilder(t *testing.T) {
+	analyzerConfigs, _ := loadPluginConfigs(t)
+
+	t.Run("valid", func(t *testing.T) {
+		runtimeConfiguration, err := gointelowl.NewRuntimeConfigurationBuilder(analyzerConfigs).
+			Set("Strings_Info", "max_number_of_strings", 1000).
+			Set("Strings_Info", "rank_strings", true).
+			Set("VirusTotal_v3_Get_Observable", "relationships_to_request", []string{"resolutions"}).
+			Set("Classic_DNS", "query_type", "MX").
+			Build()
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		want := map[string]interface{}{
+			"Strings_Info":                 map[string]interface{}{"max_number_of_strings": 1000, "rank_strings": true},
+			"VirusTotal_v3_Get_Observable": map[string]interface{}{"relationships_to_request": []string{"resolutions"}},
+			"Classic_DNS":                  map[string]interface{}{"query_type": "MX"},
+		}
+		if diff := cmp.Diff(want, runtimeConfiguration); diff != "" {
+			t.Fatalf(diff)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := gointelowl.NewRuntimeConfigurationBuilder(analyzerConfigs).
+			Set("Strings_Info", "max_number_of_string", 1000).
+			Set("Strings_Info", "rank_strings", "yes").
+			Set("Classic_DNS", "query_type", 1).
+			Set("Nope", "query_type", "A").
+			Build()
+		var runtimeConfigurationError *gointelowl.RuntimeConfigurationError
+		if !errors.As(err, &runtimeConfigurationError) {
+			t.Fatalf("Error: %v", err)
+		}
+		want := []string{
+			"the parameter query_type of Classic_DNS is of type str, got int",
+			"there is no analyzer named Nope",
+			"Strings_Info has no parameter named max_number_of_string (did you mean max_number_of_strings?)",
+			"the parameter rank_strings of Strings_Info is of type bool, got string",
+		}
+		if diff := cmp.Diff(want, runtimeConfigurationError.Problems); diff != "" {
+			t.Fatalf(diff)
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		builder := gointelowl.NewRuntimeConfigurationBuilder(analyzerConfigs).
+			Set("Strings_Info", "max_number_of_strings", 1000.0)
+		params := &gointelowl.BasicAnalysisParams{AnalyzersRequested: []string{"File_Info"}}
+		err := builder.Apply(params)
+		if err == nil || err.Error() != "Invalid runtime configuration: Strings_Info is configured but not requested" {
+			t.Fatalf("Error: %v", err)
+		}
+		params.AnalyzersRequested = append(params.AnalyzersRequested, "Strings_Info")
+		if err := builder.Apply(params); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		if diff := cmp.Diff(map[string]interface{}{"Strings_Info": map[string]interface{}{"max_number_of_strings": 1000.0}}, params.RuntimeConfiguration); diff != "" {
+			t.Fatalf(diff)
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		builder := gointelowl.NewRuntimeConfigurationBuilder(analyzerConfigs)
+		err := builder.Validate(map[string]interface{}{"Strings_Info": map[string]interface{}{"max_number_of_strings": 10.5}})
+		if err == nil || err.Error() != "Invalid runtime configuration: the parameter max_number_of_strings of Strings_Info is of type int, got float64" {
+			t.Fatalf("Error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+func TestJobStatus(t *testing.T) {
+	testCases := map[gointelowl.JobStatus][3]bool{
+		// * IsKnown, IsTerminal, IsSuccessful
+		gointelowl.JobStatusPending:              {true, false, false},
+		gointelowl.JobStatusRunning:              {true, false, false},
+		gointelowl.JobStatusReportedWithoutFails: {true, true, true},
+		gointelowl.JobStatusReportedWithFails:    {true, true, false},
+		gointelowl.JobStatusKilled:               {true, true, false},
+		gointelowl.JobStatusFailed:               {true, true, false},
+		"connectors_running":                     {false, false, false},
+	}
+	for status, want := range testCases {
+		t.Run(status.String(), func(t *testing.T) {
+			got := [3]bool{status.IsKnown(), status.IsTerminal(), status.IsSuccessful()}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func TestReportStatus(t *testing.T) {
+	testCases := map[gointelowl.ReportStatus][3]bool{
+		// * IsKnown, IsTerminal, IsSuccessful
+		gointelowl.ReportStatusPending: {true, false, false},
+		gointelowl.ReportStatusRunning: {true, false, false},
+		gointelowl.ReportStatusSuccess: {true, true, true},
+		gointelowl.ReportStatusFailed:  {true, true, false},
+		gointelowl.ReportStatusKilled:  {true, true, false},
+		"SKIPPED":                      {false, false, false},
+	}
+	for status, want := range testCases {
+		t.Run(status.String(), func(t *testing.T) {
+			got := [3]bool{status.IsKnown(), status.IsTerminal(), status.IsSuccessful()}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	jobJson := `{"id":1,"status":"REPORTED_WITH_FAILS","analyzer_reports":[{"name":"Classic_DNS","status":"success"},{"name":"Phishstats","status":"SKIPPED"},{"name":"Yara","status":null}],"connector_reports":[]}`
+	job := gointelowl.Job{}
+	if err := json.Unmarshal([]byte(jobJson), &job); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	want := []interface{}{gointelowl.JobStatusReportedWithFails, gointelowl.ReportStatusSuccess, gointelowl.ReportStatus("SKIPPED"), gointelowl.ReportStatus("")}
+	got := []interface{}{job.Status, job.AnalyzerReports[0].Status, job.AnalyzerReports[1].Status, job.AnalyzerReports[2].Status}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf(diff)
+	}
+
+	marshaled, err := json.Marshal(job.AnalyzerReports[1])
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	report := gointelowl.Report{}
+	if err := json.Unmarshal(marshaled, &report); err != nil || report.Status != "SKIPPED" {
+		t.Fatalf("Unknown statuses should survive a round trip, got %q, %v", report.Status, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"status":5}`), &job); err == nil {
+		t.Fatalf("Expected an error for a status that is not a string")
+	}
+}
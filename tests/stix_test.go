@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+	"github.com/intelowlproject/go-intelowl/stix"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Helper test
+// Checking the bundle against the STIX 2.1 schemas, then comparing it with the golden file in testFiles/stix
+func testGoldenBundle(t *testing.T, bundle *stix.Bundle, goldenFileName string) {
+	t.Helper()
+	if err := bundle.CheckRequiredProperties(); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	bundleJson, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	testStixSchemas(t, bundleJson)
+	compareWithGoldenFile(t, bundle, "stix", goldenFileName)
+}
+
+// stixSchemaBaseUrl is where the official STIX 2.1 JSON schemas vendored in testFiles/stix/schemas come from.
+const stixSchemaBaseUrl = "http://raw.githubusercontent.com/oasis-open/cti-stix2-json-schemas/stix2.1/schemas/"
+
+// Helper test
+// Validating the bundle and every one of its objects against the STIX 2.1 JSON schemas in testFiles/stix/schemas
+func testStixSchemas(t *testing.T, bundleJson []byte) {
+	t.Helper()
+	schemaDirectory := path.Join("./testFiles/", "stix", "schemas")
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	// * the schemas reference each other through their URLs, they are all loaded from testFiles
+	schemaNames := []string{}
+	err := filepath.Walk(schemaDirectory, func(schemaPath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(schemaPath) != ".json" {
+			return err
+		}
+		schemaName, err := filepath.Rel(schemaDirectory, schemaPath)
+		if err != nil {
+			return err
+		}
+		schemaName = filepath.ToSlash(schemaName)
+		schemaFile, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return err
+		}
+		schemaNames = append(schemaNames, schemaName)
+		return compiler.AddResource(stixSchemaBaseUrl+schemaName, bytes.NewReader(schemaFile))
+	})
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	validate := func(schemaName string, value interface{}) {
+		t.Helper()
+		schema, err := compiler.Compile(stixSchemaBaseUrl + schemaName)
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		if err := schema.Validate(value); err != nil {
+			t.Fatalf("Invalid STIX: %#v", err)
+		}
+	}
+
+	var bundle map[string]interface{}
+	if err := json.Unmarshal(bundleJson, &bundle); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	validate("common/bundle.json", bundle)
+	objects, _ := bundle["objects"].([]interface{})
+	for _, object := range objects {
+		objectType, _ := object.(map[string]interface{})["type"].(string)
+		found := false
+		for _, schemaName := range schemaNames {
+			if path.Base(schemaName) == objectType+".json" {
+				validate(schemaName, object)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("No STIX 2.1 schema for the %s objects", objectType)
+		}
+	}
+}
+
+// Helper test
+// Making a job with fixed times out of the analyzer reports in testFiles
+func loadStixJob(t *testing.T) *gointelowl.Job {
+	t.Helper()
+	job := loadReportedJob(t)
+	receivedRequestTime := time.Date(2022, 7, 15, 20, 25, 47, 0, time.UTC)
+	finishedAnalysisTime := receivedRequestTime.Add(12 * time.Second)
+	job.ReceivedRequestTime = &receivedRequestTime
+	job.FinishedAnalysisTime = &finishedAnalysisTime
+	job.Tags = []gointelowl.Tag{{ID: 1, Label: "dns", Color: "#ff0000"}, {ID: 2, Label: "google", Color: "#00ff00"}}
+	return job
+}
+
+func TestStixExportIndicator(t *testing.T) {
+	job := loadStixJob(t)
+	job.ObservableName = "8.8.8.8"
+	job.ObservableClassification = gointelowl.IPClassification
+	job.Tlp = "AMBER"
+	bundle, err := stix.Export(job)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	testGoldenBundle(t, bundle, "indicator.json")
+
+	// * the Yara hook adds the malware indicated by the indicator
+	malwares := bundle.ObjectsOfType("malware")
+	relationships := bundle.ObjectsOfType("relationship")
+	if len(malwares) != 1 || malwares[0]["name"] != "Windows_Trojan_Generic" {
+		t.Fatalf("Got %v, want the Windows_Trojan_Generic malware", malwares)
+	}
+	if len(relationships) != 1 || relationships[0]["target_ref"] != malwares[0].ID() {
+		t.Fatalf("Got %v, want the malware to be indicated", relationships)
+	}
+
+	// * exporting twice gives the same bundle
+	otherBundle, err := stix.Export(job)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if diff := cmp.Diff(bundle, otherBundle); diff != "" {
+		t.Fatalf(diff)
+	}
+}
+
+func TestStixExportObservedData(t *testing.T) {
+	job := loadStixJob(t)
+	job.IsSample = true
+	job.FileName = "invoice.pdf"
+	job.FileMimetype = "application/pdf"
+	job.Md5 = "a5b6f1c4b0d2e3f4a5b6c7d8e9f0a1b2"
+	job.Tlp = "RED"
+	job.Tags = nil
+	exporter := stix.NewExporter(&stix.ExporterOptions{Mode: stix.AsObservedData, IdentityName: "ACME IntelOwl"})
+	bundle, err := exporter.Export(job)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	testGoldenBundle(t, bundle, "observedData.json")
+}
+
+func TestStixExportHook(t *testing.T) {
+	job := loadStixJob(t)
+	job.ObservableName = "evil.com"
+	job.ObservableClassification = gointelowl.DomainClassification
+	exporter := stix.NewExporter(nil)
+	exporter.RegisterHook("Yara", func(hookContext *stix.HookContext) ([]stix.Object, error) {
+		return nil, nil
+	})
+	exporter.RegisterHook("MaxMindGeoIP", func(hookContext *stix.HookContext) ([]stix.Object, error) {
+		infrastructure := hookContext.NewObject("infrastructure", "hosting")
+		infrastructure["name"] = "Hosting of " + hookContext.Job.ObservableName
+		infrastructure["infrastructure_types"] = []string{"hosting-malware"}
+		return []stix.Object{
+			infrastructure,
+			hookContext.Relationship(hookContext.ObservableRef, "indicates", infrastructure.ID()),
+		}, nil
+	})
+	bundle, err := exporter.Export(job)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	testGoldenBundle(t, bundle, "hook.json")
+
+	exporter.RegisterHook("MaxMindGeoIP", func(hookContext *stix.HookContext) ([]stix.Object, error) {
+		return nil, gointelowl.ErrNotScored
+	})
+	if _, err := exporter.Export(job); err == nil || !strings.HasPrefix(err.Error(), "Could not export the report of MaxMindGeoIP") {
+		t.Fatalf("Got %v, want the error of the hook", err)
+	}
+
+	job.Tlp = "PURPLE"
+	if _, err := exporter.Export(job); err == nil {
+		t.Fatalf("Expected an error for an unknown TLP")
+	}
+}
+
+func TestStixCheckRequiredProperties(t *testing.T) {
+	object := `{"type":"indicator","spec_version":"2.1","id":"indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f",` +
+		`"created":"2022-07-15T20:25:47.000Z","modified":"2022-07-15T20:25:47.000Z",` +
+		`"pattern":"[domain-name:value = 'evil.com']","pattern_type":"stix","valid_from":"2022-07-15T20:25:47.000Z"%s}`
+	bundle := `{"type":"bundle","id":"bundle--44af6c39-c09b-49c5-9de2-394224b04982","objects":[` + object + `]}`
+	// * table test case
+	testCases := map[string]TestData{
+		"valid":                 {Input: strings.Replace(bundle, "%s", "", 1), Want: ""},
+		"notJson":               {Input: "[]", Want: "the bundle is not a JSON object"},
+		"bundleID":              {Input: strings.Replace(strings.Replace(bundle, "%s", "", 1), "bundle--", "report--", 1), Want: "the bundle: id must be an identifier of type bundle"},
+		"customProperty":        {Input: strings.Replace(bundle, "%s", `,"x_Score":1`, 1), Want: `invalid property name "x_Score"`},
+		"reference":             {Input: strings.Replace(bundle, "%s", `,"created_by_ref":"identity--1"`, 1), Want: "created_by_ref must be an identifier"},
+		"missingProperty":       {Input: strings.Replace(strings.Replace(bundle, "%s", "", 1), `"pattern_type":"stix",`, "", 1), Want: "the pattern_type property is required"},
+		"modifiedBeforeCreated": {Input: strings.Replace(strings.Replace(bundle, "%s", "", 1), `"modified":"2022-07-15T20:25:47.000Z"`, `"modified":"2021-07-15T20:25:47.000Z"`, 1), Want: "modified must not be before created"},
+		"notUTC":                {Input: strings.Replace(strings.Replace(bundle, "%s", "", 1), `"valid_from":"2022-07-15T20:25:47.000Z"`, `"valid_from":"2022-07-15T20:25:47+02:00"`, 1), Want: "valid_from must be a UTC timestamp"},
+		"specVersion":           {Input: strings.Replace(strings.Replace(bundle, "%s", "", 1), `"spec_version":"2.1",`, "", 1), Want: "spec_version must be 2.1"},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			bundleJson, ok := testCase.Input.(string)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			want, ok := testCase.Want.(string)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			err := stix.CheckRequiredProperties([]byte(bundleJson))
+			if want == "" {
+				if err != nil {
+					t.Fatalf("Error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Fatalf("Got %v, want an error with %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+// Helper test
+// Making a job out of the analyzer reports in testFiles
+func loadReportedJob(t *testing.T) *gointelowl.Job {
+	t.Helper()
+	reports := loadAnalyzerReports(t)
+	names := make([]string, 0, len(reports))
+	for name := range reports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	job := &gointelowl.Job{}
+	job.ID = 42
+	job.Status = gointelowl.JobStatusReportedWithFails
+	for _, name := range names {
+		job.AnalyzerReports = append(job.AnalyzerReports, reports[name])
+	}
+	job.AnalyzerReports = append(job.AnalyzerReports,
+		gointelowl.Report{Name: "GoogleWebRisk", Status: gointelowl.ReportStatusFailed, Errors: []string{"Missing service account keyfile"}, ProcessTime: 0.09, Type: "analyzer"},
+		gointelowl.Report{Name: "Phishstats", Status: gointelowl.ReportStatusRunning, Type: "analyzer"},
+	)
+	return job
+}
+
+func TestJobSummarize(t *testing.T) {
+	job := loadReportedJob(t)
+	jobSummary := job.Summarize()
+	want := &gointelowl.JobSummary{
+		JobID:   42,
+		Score:   0.1,
+		Verdict: gointelowl.VerdictBenign,
+		Verdicts: []gointelowl.AnalyzerVerdict{
+			{Analyzer: "AbuseIPDB", Score: 0, Verdict: gointelowl.VerdictBenign},
+			{Analyzer: "File_Info", Verdict: gointelowl.VerdictUnknown, Reason: "no scoring rule"},
+			{Analyzer: "MaxMindGeoIP", Verdict: gointelowl.VerdictUnknown, Reason: "no scoring rule"},
+			{Analyzer: "OTXQuery", Score: 0.1, Verdict: gointelowl.VerdictBenign},
+			{Analyzer: "Shodan_Honeyscore", Verdict: gointelowl.VerdictUnknown, Reason: "no scoring rule"},
+			{Analyzer: "Strings_Info", Verdict: gointelowl.VerdictUnknown, Reason: "no scoring rule"},
+			{Analyzer: "VirusTotal_v3_Get_Observable", Score: 4.0 / 89, Verdict: gointelowl.VerdictBenign},
+			{Analyzer: "Yara", Verdict: gointelowl.VerdictUnknown, Reason: "no scoring rule"},
+		},
+		Failed: []gointelowl.FailedAnalyzer{
+			{Analyzer: "GoogleWebRisk", Status: gointelowl.ReportStatusFailed, Errors: []string{"Missing service account keyfile"}},
+		},
+		TotalProcessTime: 7.82,
+		MaxProcessTime:   3.1,
+		SlowestAnalyzer:  "Yara",
+	}
+	if diff := cmp.Diff(want, jobSummary, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf(diff)
+	}
+}
+
+func TestSummarizerRules(t *testing.T) {
+	job := loadReportedJob(t)
+	summarizer := gointelowl.NewSummarizer()
+	summarizer.Register("Yara", func(report *gointelowl.Report) (float64, error) {
+		yaraReport := gointelowl.YaraReport{}
+		if err := report.Decode(&yaraReport); err != nil {
+			return 0, err
+		}
+		return float64(len(yaraReport.Matches())) * 0.8, nil
+	})
+	summarizer.Register("Shodan_Honeyscore", func(report *gointelowl.Report) (float64, error) {
+		return 0, gointelowl.ErrNotScored
+	})
+	jobSummary := summarizer.Summarize(job)
+	if jobSummary.Verdict != gointelowl.VerdictMalicious || jobSummary.Score != 0.8 {
+		t.Fatalf("Got %s with score %f, want malicious with score 0.8", jobSummary.Verdict, jobSummary.Score)
+	}
+	verdicts := map[string]gointelowl.AnalyzerVerdict{}
+	for _, analyzerVerdict := range jobSummary.Verdicts {
+		verdicts[analyzerVerdict.Analyzer] = analyzerVerdict
+	}
+	if diff := cmp.Diff(gointelowl.AnalyzerVerdict{Analyzer: "Shodan_Honeyscore", Verdict: gointelowl.VerdictUnknown, Reason: "Not scored"}, verdicts["Shodan_Honeyscore"]); diff != "" {
+		t.Fatalf(diff)
+	}
+
+	summarizer.SuspiciousThreshold = 0.05
+	summarizer.Register("Yara", func(report *gointelowl.Report) (float64, error) {
+		return 0, nil
+	})
+	if jobSummary := summarizer.Summarize(job); jobSummary.Verdict != gointelowl.VerdictSuspicious {
+		t.Fatalf("Got %s, want suspicious", jobSummary.Verdict)
+	}
+
+	emptyJob := &gointelowl.Job{}
+	if jobSummary := emptyJob.Summarize(); jobSummary.Verdict != gointelowl.VerdictUnknown {
+		t.Fatalf("Got %s, want unknown", jobSummary.Verdict)
+	}
+}
@@ -76,6 +76,7 @@ func TestTagServiceGet(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusNotFound,
 			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
 		},
 	}
 
@@ -130,6 +131,9 @@ func TestTagServiceCreate(t *testing.T) {
 		Want: &gointelowl.IntelOwlError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"label":["tag with this label already exists."]}`,
+			FieldErrors: map[string][]string{
+				"label": {"tag with this label already exists."},
+			},
 		},
 	}
 	for name, testCase := range testCases {
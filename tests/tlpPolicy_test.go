@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/intelowlproject/go-intelowl/constants"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+func TestTLPCompare(t *testing.T) {
+	if gointelowl.GREEN.Compare(gointelowl.AMBER) != -1 || gointelowl.RED.Compare(gointelowl.WHITE) != 1 || gointelowl.AMBER.Compare(gointelowl.AMBER) != 0 {
+		t.Errorf("Wrong TLP ordering")
+	}
+	if !gointelowl.RED.IsMoreRestrictiveThan(gointelowl.AMBER) || gointelowl.GREEN.IsMoreRestrictiveThan(gointelowl.GREEN) {
+		t.Errorf("Wrong IsMoreRestrictiveThan")
+	}
+	if !gointelowl.GREEN.AllowedBy(gointelowl.AMBER) || gointelowl.AMBER.AllowedBy(gointelowl.GREEN) || gointelowl.TLP(0).AllowedBy(gointelowl.RED) {
+		t.Errorf("Wrong AllowedBy")
+	}
+	if gointelowl.ParseTLP(" clear ") != gointelowl.WHITE {
+		t.Errorf("CLEAR should be parsed as WHITE")
+	}
+}
+
+func TestTLPUnmarshalJSON(t *testing.T) {
+	testCases := make(map[string]TestData)
+	testCases["amber"] = TestData{
+		Input: `"AMBER"`,
+		Want:  gointelowl.AMBER,
+	}
+	testCases["clear"] = TestData{
+		Input: `"CLEAR"`,
+		Want:  gointelowl.WHITE,
+	}
+	testCases["null"] = TestData{
+		Input: `null`,
+		Want:  gointelowl.GREEN,
+	}
+	testCases["unknown"] = TestData{
+		Input: `"PURPLE"`,
+		Want:  errors.New(`Unknown TLP "PURPLE": use WHITE (or CLEAR), GREEN, AMBER or RED`),
+	}
+	testCases["notAString"] = TestData{
+		Input: `3`,
+		Want:  errors.New("json: cannot unmarshal number into Go value of type string"),
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tlp := gointelowl.GREEN
+			err := json.Unmarshal([]byte(testCase.Input.(string)), &tlp)
+			if wantErr, ok := testCase.Want.(error); ok {
+				if err == nil || err.Error() != wantErr.Error() {
+					t.Fatalf("Error: got %v, want %s", err, wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			if diff := cmp.Diff(testCase.Want, tlp); diff != "" {
+				t.Fatalf(diff)
+			}
+		})
+	}
+}
+
+func TestTLPPolicyUnknownMaximumTlp(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.CONNECTOR_CONFIG_URL, serverHandler(t, TestData{
+		Data:       `{"MISP":{"name":"MISP","maximum_tlp":"AMBER+STRICT"},"YETI":{"name":"YETI","maximum_tlp":"GREEN"}}`,
+		StatusCode: http.StatusOK,
+	}, "GET"))
+	ctx := context.Background()
+	// * one odd maximum TLP does not fail the whole configuration
+	tlpPolicy, err := client.NewTLPPolicy(ctx, gointelowl.StripTLPViolations)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	params := &gointelowl.BasicAnalysisParams{Tlp: gointelowl.WHITE, ConnectorsRequested: []string{"MISP", "YETI"}}
+	decision, err := tlpPolicy.Enforce(params)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	// * but no data goes to the connector with the unknown maximum TLP
+	testWantData(t, []string{"YETI"}, params.ConnectorsRequested)
+	testWantData(t, "the connector MISP has no maximum TLP known to the client", decision.Violations[0].Reason)
+}
+
+func TestTLPUnmarshalJSONPayload(t *testing.T) {
+	// * the connector configurations are the only lenient payloads
+	params := gointelowl.BasicAnalysisParams{}
+	err := json.Unmarshal([]byte(`{"user":1,"tlp":"AMBER+STRICT","runtime_configuration":{}}`), &params)
+	if err == nil || err.Error() != `Unknown TLP "AMBER+STRICT": use WHITE (or CLEAR), GREEN, AMBER or RED` {
+		t.Fatalf("Got %v, want an unknown TLP error", err)
+	}
+	decision := gointelowl.TLPDecision{}
+	if err := json.Unmarshal([]byte(`{"tlp":"PURPLE"}`), &decision); err == nil {
+		t.Fatalf("Expected an unknown TLP error")
+	}
+}
+
+func TestTLPPolicyEnforce(t *testing.T) {
+	_, connectorConfigs := loadPluginConfigs(t)
+	// * MISP accepts up to AMBER, OpenCTI WHITE and YETI GREEN
+	testCases := map[string]struct {
+		action         gointelowl.TLPAction
+		tlp            gointelowl.TLP
+		connectors     []string
+		wantConnectors []string
+		wantAllowed    []string
+		wantViolations []string
+		wantBlocked    bool
+		wantStripped   bool
+	}{
+		"allowed": {
+			action:         gointelowl.BlockTLPViolations,
+			tlp:            gointelowl.GREEN,
+			connectors:     []string{"MISP", "YETI"},
+			wantConnectors: []string{"MISP", "YETI"},
+			wantAllowed:    []string{"MISP", "YETI"},
+			wantViolations: []string{},
+		},
+		"block": {
+			action:         gointelowl.BlockTLPViolations,
+			tlp:            gointelowl.AMBER,
+			connectors:     []string{"MISP", "YETI"},
+			wantConnectors: []string{"MISP", "YETI"},
+			wantAllowed:    []string{"MISP"},
+			wantViolations: []string{"YETI"},
+			wantBlocked:    true,
+		},
+		"strip": {
+			action:         gointelowl.StripTLPViolations,
+			tlp:            gointelowl.AMBER,
+			connectors:     []string{"MISP", "YETI", "Nope"},
+			wantConnectors: []string{"MISP"},
+			wantAllowed:    []string{"MISP"},
+			wantViolations: []string{"YETI", "Nope"},
+			wantStripped:   true,
+		},
+		"strip every connector": {
+			action:         gointelowl.StripTLPViolations,
+			tlp:            gointelowl.RED,
+			connectors:     []string{"MISP"},
+			wantConnectors: []string{"MISP"},
+			wantAllowed:    []string{},
+			wantViolations: []string{"MISP"},
+			wantBlocked:    true,
+		},
+		"all connectors": {
+			action:         gointelowl.StripTLPViolations,
+			tlp:            gointelowl.GREEN,
+			connectors:     nil,
+			wantConnectors: []string{"MISP", "YETI"},
+			wantAllowed:    []string{"MISP", "YETI"},
+			wantViolations: []string{"OpenCTI"},
+			wantStripped:   true,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tlpPolicy := gointelowl.NewTLPPolicy(connectorConfigs, testCase.action)
+			audited := []gointelowl.TLPDecision{}
+			tlpPolicy.Audit = func(decision gointelowl.TLPDecision) {
+				audited = append(audited, decision)
+			}
+			params := &gointelowl.BasicAnalysisParams{
+				Tlp:                 testCase.tlp,
+				ConnectorsRequested: testCase.connectors,
+			}
+			decision, err := tlpPolicy.Enforce(params)
+			if testCase.wantBlocked != errors.Is(err, gointelowl.ErrTLPViolation) {
+				t.Fatalf("Error: %v", err)
+			}
+			violations := []string{}
+			for _, violation := range decision.Violations {
+				violations = append(violations, violation.Connector)
+			}
+			wantConnectors := testCase.wantConnectors
+			if testCase.connectors == nil && !testCase.wantStripped {
+				wantConnectors = nil
+			}
+			if diff := cmp.Diff(wantConnectors, params.ConnectorsRequested); diff != "" {
+				t.Fatalf(diff)
+			}
+			if diff := cmp.Diff(testCase.wantAllowed, decision.Allowed); diff != "" {
+				t.Fatalf(diff)
+			}
+			if diff := cmp.Diff(testCase.wantViolations, violations); diff != "" {
+				t.Fatalf(diff)
+			}
+			if decision.Blocked != testCase.wantBlocked || decision.Stripped != testCase.wantStripped {
+				t.Fatalf("Blocked %t and Stripped %t", decision.Blocked, decision.Stripped)
+			}
+			if len(audited) != 1 || audited[0].Time != decision.Time {
+				t.Fatalf("The decision was not audited")
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/intelowlproject/go-intelowl/constants"
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+	"github.com/sirupsen/logrus"
+)
+
+// Helper
+// Writing PEM blocks to a file in the test's temporary directory
+func writePEM(t *testing.T, fileName string, blockType string, data []byte) string {
+	t.Helper()
+	filePath := path.Join(t.TempDir(), fileName)
+	if err := ioutil.WriteFile(filePath, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600); err != nil {
+		t.Fatalf("Could not write %s: %s", filePath, err)
+	}
+	return filePath
+}
+
+// Helper
+// Making a self signed client certificate and writing it and its key to files
+func writeClientCertificate(t *testing.T) (certificatePath string, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-intelowl"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Could not create certificate: %s", err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Could not marshal key: %s", err)
+	}
+	return writePEM(t, "client.pem", "CERTIFICATE", certificate), writePEM(t, "client.key", "EC PRIVATE KEY", keyBytes)
+}
+
+func newTLSTestClient(options *gointelowl.IntelOwlClientOptions) gointelowl.IntelOwlClient {
+	return gointelowl.NewIntelOwlClient(
+		options,
+		nil,
+		&gointelowl.LoggerParams{
+			Level: logrus.DebugLevel,
+		},
+	)
+}
+
+func TestTLSOptions(t *testing.T) {
+	apiHandler := http.NewServeMux()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	testServer := httptest.NewTLSServer(apiHandler)
+	defer testServer.Close()
+	serverCertificate := testServer.Certificate()
+	caPath := writePEM(t, "ca.pem", "CERTIFICATE", serverCertificate.Raw)
+	invalidCaPath := path.Join(t.TempDir(), "invalid.pem")
+	_ = ioutil.WriteFile(invalidCaPath, []byte("not a certificate"), 0600)
+	serverPin := sha256.Sum256(serverCertificate.RawSubjectPublicKeyInfo)
+	otherPin := sha256.Sum256([]byte("another public key"))
+
+	// * table test case
+	testCases := map[string]struct {
+		options         gointelowl.IntelOwlClientOptions
+		wantConfigError bool
+		wantError       bool
+	}{
+		"untrusted": {
+			options:   gointelowl.IntelOwlClientOptions{},
+			wantError: true,
+		},
+		"customCA": {
+			options: gointelowl.IntelOwlClientOptions{Certificate: caPath},
+		},
+		"missingCA": {
+			options:         gointelowl.IntelOwlClientOptions{Certificate: path.Join(t.TempDir(), "missing.pem")},
+			wantConfigError: true,
+		},
+		"invalidCA": {
+			options:         gointelowl.IntelOwlClientOptions{Certificate: invalidCaPath},
+			wantConfigError: true,
+		},
+		"invalidMinTLSVersion": {
+			options:         gointelowl.IntelOwlClientOptions{Certificate: caPath, MinTLSVersion: "2.0"},
+			wantConfigError: true,
+		},
+		"matchingPin": {
+			options: gointelowl.IntelOwlClientOptions{
+				Certificate:      caPath,
+				PinnedPublicKeys: []string{base64.StdEncoding.EncodeToString(otherPin[:]), "sha256//" + base64.StdEncoding.EncodeToString(serverPin[:])},
+			},
+		},
+		"mismatchingPin": {
+			options: gointelowl.IntelOwlClientOptions{
+				Certificate:      caPath,
+				PinnedPublicKeys: []string{base64.StdEncoding.EncodeToString(otherPin[:])},
+			},
+			wantError: true,
+		},
+		"invalidPin": {
+			options:         gointelowl.IntelOwlClientOptions{Certificate: caPath, PinnedPublicKeys: []string{"not-a-pin"}},
+			wantConfigError: true,
+		},
+		"keyWithoutCertificate": {
+			options:         gointelowl.IntelOwlClientOptions{Certificate: caPath, ClientKey: caPath},
+			wantConfigError: true,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			options := testCase.options
+			options.Url = testServer.URL
+			client := newTLSTestClient(&options)
+			testWantData(t, testCase.wantConfigError, client.Err() != nil)
+			_, err := client.TagService.List(context.Background())
+			testWantData(t, testCase.wantError || testCase.wantConfigError, err != nil)
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	apiHandler := http.NewServeMux()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+	testServer := httptest.NewUnstartedServer(apiHandler)
+	testServer.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	testServer.StartTLS()
+	defer testServer.Close()
+	caPath := writePEM(t, "ca.pem", "CERTIFICATE", testServer.Certificate().Raw)
+	certificatePath, keyPath := writeClientCertificate(t)
+
+	client := newTLSTestClient(&gointelowl.IntelOwlClientOptions{
+		Url:               testServer.URL,
+		Certificate:       caPath,
+		ClientCertificate: certificatePath,
+		ClientKey:         keyPath,
+		MinTLSVersion:     "1.2",
+	})
+	if _, err := client.TagService.List(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	clientWithoutCertificate := newTLSTestClient(&gointelowl.IntelOwlClientOptions{
+		Url:         testServer.URL,
+		Certificate: caPath,
+	})
+	if _, err := clientWithoutCertificate.TagService.List(context.Background()); err == nil {
+		t.Fatalf("Expected the handshake to fail without a client certificate")
+	}
+}
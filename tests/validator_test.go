@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/intelowlproject/go-intelowl/gointelowl"
+)
+
+// Helper test
+// Loading the analyzer and connector configurations in testFiles, sorted by name like the services do
+func loadPluginConfigs(t *testing.T) ([]gointelowl.AnalyzerConfig, []gointelowl.ConnectorConfig) {
+	t.Helper()
+	analyzerConfigsJson, _ := os.ReadFile(path.Join("./testFiles/", "analyzerConfigs.json"))
+	analyzerConfigsMap := map[string]gointelowl.AnalyzerConfig{}
+	if unmarshalError := json.Unmarshal(analyzerConfigsJson, &analyzerConfigsMap); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	connectorConfigsJson, _ := os.ReadFile(path.Join("./testFiles/", "connectorConfigs.json"))
+	connectorConfigsMap := map[string]gointelowl.ConnectorConfig{}
+	if unmarshalError := json.Unmarshal(connectorConfigsJson, &connectorConfigsMap); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	analyzerConfigs := []gointelowl.AnalyzerConfig{}
+	for _, analyzerConfig := range analyzerConfigsMap {
+		analyzerConfigs = append(analyzerConfigs, analyzerConfig)
+	}
+	sort.Slice(analyzerConfigs, func(i, j int) bool { return analyzerConfigs[i].Name < analyzerConfigs[j].Name })
+	connectorConfigs := []gointelowl.ConnectorConfig{}
+	for _, connectorConfig := range connectorConfigsMap {
+		connectorConfigs = append(connectorConfigs, connectorConfig)
+	}
+	sort.Slice(connectorConfigs, func(i, j int) bool { return connectorConfigs[i].Name < connectorConfigs[j].Name })
+	return analyzerConfigs, connectorConfigs
+}
+
+func TestPluginValidatorObservable(t *testing.T) {
+	analyzerConfigs, connectorConfigs := loadPluginConfigs(t)
+	wantSkipped := []gointelowl.SkippedPlugin{
+		{Name: "File_Info", PluginType: "analyzer", Reason: gointelowl.SkipWrongType, Detail: "File_Info analyzes files, not observables"},
+		{Name: "VirusTotal_v3_Get_Observable", PluginType: "analyzer", Reason: gointelowl.SkipNotConfigured, Detail: "the analyzer VirusTotal_v3_Get_Observable is not configured (missing secrets: api_key_name)"},
+		{Name: "Shodan_Honeyscore", PluginType: "analyzer", Reason: gointelowl.SkipDisabled, Detail: "the analyzer Shodan_Honeyscore is disabled"},
+		{Name: "Nope", PluginType: "analyzer", Reason: gointelowl.SkipUnknown, Detail: "there is no analyzer named Nope"},
+		{Name: "YETI", PluginType: "connector", Reason: gointelowl.SkipNotConfigured, Detail: "the connector YETI is not configured (missing secrets: api_key_name)"},
+	}
+	newParams := func() *gointelowl.ObservableAnalysisParams {
+		return &gointelowl.ObservableAnalysisParams{
+			BasicAnalysisParams: gointelowl.BasicAnalysisParams{
+				AnalyzersRequested:  []string{"Classic_DNS", "File_Info", "VirusTotal_v3_Get_Observable", "Shodan_Honeyscore", "Nope"},
+				ConnectorsRequested: []string{"MISP", "YETI"},
+			},
+			ObservableName: "dns.google",
+		}
+	}
+	t.Run("report", func(t *testing.T) {
+		params := newParams()
+		validator := gointelowl.NewPluginValidator(analyzerConfigs, connectorConfigs, gointelowl.ReportSkipped)
+		report, err := validator.ValidateObservable(params)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		testWantData(t, &gointelowl.ValidationReport{
+			Analyzers:  []string{"Classic_DNS"},
+			Connectors: []string{"MISP"},
+			Skipped:    wantSkipped,
+		}, report)
+		testWantData(t, newParams(), params)
+	})
+	t.Run("fail", func(t *testing.T) {
+		validator := gointelowl.NewPluginValidator(analyzerConfigs, connectorConfigs, gointelowl.FailOnSkipped)
+		_, err := validator.ValidateObservable(newParams())
+		validationError := &gointelowl.PluginValidationError{}
+		if !errors.As(err, &validationError) {
+			t.Fatalf("Expected a PluginValidationError, got %v", err)
+		}
+		testWantData(t, wantSkipped, validationError.Report.Skipped)
+	})
+	t.Run("prune", func(t *testing.T) {
+		params := newParams()
+		validator := gointelowl.NewPluginValidator(analyzerConfigs, connectorConfigs, gointelowl.PruneSkipped)
+		report, err := validator.ValidateObservable(params)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		testWantData(t, true, report.Pruned)
+		testWantData(t, []string{"Classic_DNS"}, params.AnalyzersRequested)
+		testWantData(t, []string{"MISP"}, params.ConnectorsRequested)
+	})
+	t.Run("unsupportedObservable", func(t *testing.T) {
+		params := newParams()
+		params.ObservableName = "40ff44d9e619b17524bf3763204f9cbb"
+		params.AnalyzersRequested = []string{"Classic_DNS"}
+		params.ConnectorsRequested = nil
+		validator := gointelowl.NewPluginValidator(analyzerConfigs, connectorConfigs, gointelowl.PruneSkipped)
+		report, err := validator.ValidateObservable(params)
+		// * pruning every analyzer would make IntelOwl run all of them
+		if err == nil {
+			t.Fatalf("Expected an error when no analyzer is left")
+		}
+		testWantData(t, gointelowl.SkipUnsupportedObservable, report.Skipped[0].Reason)
+		testWantData(t, []string{"Classic_DNS"}, params.AnalyzersRequested)
+	})
+}
+
+func TestPluginValidatorFile(t *testing.T) {
+	analyzerConfigs, connectorConfigs := loadPluginConfigs(t)
+	validator := gointelowl.NewPluginValidator(analyzerConfigs, connectorConfigs, gointelowl.ReportSkipped)
+	// * table test case
+	testCases := map[string]TestData{
+		"pdf": {
+			Input: "application/pdf",
+			Want:  []string{"File_Info", "PDF_Info"},
+		},
+		"executable": {
+			Input: "application/x-dosexec",
+			Want:  []string{"File_Info", "Strings_Info"},
+		},
+		"unknownMimetype": {
+			Input: "",
+			Want:  []string{"File_Info", "PDF_Info", "Strings_Info"},
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			mimetype, ok := testCase.Input.(string)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			report, err := validator.ValidateFile(&gointelowl.FileAnalysisParams{
+				BasicAnalysisParams: gointelowl.BasicAnalysisParams{
+					AnalyzersRequested: []string{"File_Info", "PDF_Info", "Strings_Info", "Classic_DNS"},
+				},
+			}, mimetype)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			testWantData(t, testCase.Want, report.Analyzers)
+			testWantData(t, gointelowl.SkipWrongType, report.Skipped[len(report.Skipped)-1].Reason)
+		})
+	}
+}